@@ -0,0 +1,152 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/example/named-lock/internal/post"
+)
+
+func main() {
+	// 認証トークン（デフォルト: 環境変数 NAMED_LOCK_ADMIN_TOKEN）
+	token := flag.String("token", os.Getenv("NAMED_LOCK_ADMIN_TOKEN"), "管理用APIの認証トークン")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		usage()
+		os.Exit(1)
+	}
+
+	client := post.NewClient(0)
+	client.AdminToken = *token
+
+	// コマンドに応じて処理を分岐
+	switch flag.Arg(0) {
+	case "locks":
+		listLocks(client)
+	case "release":
+		forceRelease(client, flag.Args()[1:])
+	case "jobs":
+		listJobs(client)
+	case "runs":
+		listJobRuns(client, flag.Args()[1:])
+	default:
+		fmt.Printf("未知のコマンド: %s\n", flag.Arg(0))
+		usage()
+		os.Exit(1)
+	}
+}
+
+// usage は使用方法を表示する
+func usage() {
+	fmt.Println("使用方法: go run ./cmd/admin [-token トークン] コマンド [引数...]")
+	fmt.Println("コマンド:")
+	fmt.Println("  locks: 名前付きロックの保持者と待機者の一覧を表示")
+	fmt.Println("  release [-reason 理由] [-dry-run] [-kill-query] ロック名: ロックを保持しているセッションを KILL して強制的に解放")
+	fmt.Println("  jobs: 定期実行ジョブの一覧と最後の実行結果を表示")
+	fmt.Println("  runs [-limit 件数] ジョブ名: 定期実行ジョブの実行記録を新しい順に表示")
+}
+
+// listLocks は名前付きロックの一覧を表示する
+func listLocks(client *post.Client) {
+	locksResp, err := client.ListAdminLocks()
+	if err != nil {
+		fmt.Printf("Failed to list locks: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Source: %s\n", locksResp.Source)
+	if locksResp.Warning != "" {
+		fmt.Printf("Warning: %s\n", locksResp.Warning)
+	}
+	for _, lock := range locksResp.Locks {
+		holder := "-"
+		if lock.Holder != nil {
+			holder = fmt.Sprintf("session %s (%s@%s, %ds)", lock.Holder.SessionID, lock.Holder.User, lock.Holder.Host, lock.Holder.Seconds)
+		}
+		fmt.Printf("%s: holder: %s, waiters: %d\n", lock.LockName, holder, len(lock.Waiters))
+	}
+}
+
+// forceRelease はロックを強制的に解放する
+func forceRelease(client *post.Client, args []string) {
+	fs := flag.NewFlagSet("release", flag.ExitOnError)
+	reason := fs.String("reason", "", "強制解放の理由（必須）")
+	dryRun := fs.Bool("dry-run", false, "所有者の確認と記録のみを行い、KILL は発行しない")
+	killQuery := fs.Bool("kill-query", false, "接続を切断せず、実行中のクエリのみを打ち切る（ロックは解放されない）")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 || *reason == "" {
+		fmt.Println("使用方法: go run ./cmd/admin release -reason 理由 [-dry-run] [-kill-query] ロック名")
+		os.Exit(1)
+	}
+	lockName := fs.Arg(0)
+
+	releaseResp, err := client.ForceRelease(lockName, post.ForceReleaseRequest{
+		Reason:    *reason,
+		DryRun:    *dryRun,
+		KillQuery: *killQuery,
+	})
+	if err != nil {
+		fmt.Printf("Failed to force release lock %s: %v\n", lockName, err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("%s: %s %s (session %s, %s@%s), action ID: %d\n",
+		releaseResp.LockName, releaseResp.Action, releaseResp.Result,
+		releaseResp.Owner.SessionID, releaseResp.Owner.User, releaseResp.Owner.Host, releaseResp.ActionID)
+}
+
+// listJobs は定期実行ジョブの一覧を表示する
+func listJobs(client *post.Client) {
+	jobsResp, err := client.ListJobs()
+	if err != nil {
+		fmt.Printf("Failed to list jobs: %v\n", err)
+		os.Exit(1)
+	}
+
+	for _, job := range jobsResp.Jobs {
+		fmt.Printf("%s (%s): next: %s", job.Name, job.Spec, valueOrDash(job.NextRunAt))
+		if job.HolderSessionID != "" {
+			fmt.Printf(", running on session %s", job.HolderSessionID)
+		}
+		if job.LastRun != nil {
+			fmt.Printf(", last: %s at %s (%dms, session %s, %s)", job.LastRun.Outcome, job.LastRun.StartedAt,
+				job.LastRun.DurationMs, job.LastRun.SessionID, job.LastRun.Instance)
+		}
+		fmt.Println()
+	}
+}
+
+// listJobRuns は定期実行ジョブの実行記録を表示する
+func listJobRuns(client *post.Client, args []string) {
+	fs := flag.NewFlagSet("runs", flag.ExitOnError)
+	limit := fs.Int("limit", 20, "表示する記録の最大件数")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fmt.Println("使用方法: go run ./cmd/admin runs [-limit 件数] ジョブ名")
+		os.Exit(1)
+	}
+
+	runsResp, err := client.ListJobRuns(fs.Arg(0), *limit)
+	if err != nil {
+		fmt.Printf("Failed to list job runs: %v\n", err)
+		os.Exit(1)
+	}
+
+	for _, run := range runsResp.Runs {
+		fmt.Printf("#%d scheduled: %s, started: %s, %s (%dms), session %s (%s): %s\n",
+			run.ID, run.ScheduledAt, run.StartedAt, run.Outcome, run.DurationMs, run.SessionID, run.Instance, valueOrDash(run.Message))
+	}
+}
+
+// valueOrDash は空文字列の場合に - を返す
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -20,6 +20,9 @@ func main() {
 
 	// テストモードに応じて処理を分岐
 	switch testMode {
+	case "normal", "n":
+		fmt.Println("実行モード: 通常のロックテスト")
+		post.RunNormalLockTest(startID, parallelCount)
 	case "hold", "h":
 		fmt.Println("実行モード: ロック保持・解放テスト")
 		// 保持時間の取得（デフォルト: 5秒）
@@ -37,13 +40,68 @@ func main() {
 	case "order", "o":
 		fmt.Println("実行モード: 注文ロックテスト")
 		post.RunOrderLockTest(startID, parallelCount)
+	case "decrement", "d":
+		fmt.Println("実行モード: 在庫減算テスト")
+		if !post.RunDecrementTest(startID, parallelCount) {
+			os.Exit(1)
+		}
+	case "checkout", "c":
+		fmt.Println("実行モード: 複数商品の注文テスト")
+		if !post.RunCheckoutTest(startID, parallelCount) {
+			os.Exit(1)
+		}
+	case "rw", "r":
+		fmt.Println("実行モード: 読み取り・書き込みロックテスト")
+		if !post.RunReadWriteTest(startID, parallelCount) {
+			os.Exit(1)
+		}
+	case "semaphore", "s":
+		fmt.Println("実行モード: セマフォテスト")
+		// 許可の数の取得（デフォルト: 3）
+		permits := 3
+		if len(args) > 1 {
+			if n, err := strconv.Atoi(args[1]); err == nil && n > 0 {
+				permits = n
+			}
+		}
+		fmt.Printf("許可の数: %d\n", permits)
+		if !post.RunSemaphoreTest(startID, parallelCount, permits) {
+			os.Exit(1)
+		}
+	case "fair", "f":
+		fmt.Println("実行モード: 公平モード（順番待ち）のロックテスト")
+		if !post.RunFairLockTest(startID, parallelCount) {
+			os.Exit(1)
+		}
+	case "try", "t":
+		fmt.Println("実行モード: 待機なしのロックテスト")
+		post.RunTryLockTest(startID, parallelCount)
+	case "longname", "ln":
+		fmt.Println("実行モード: 複合ロック名のテスト")
+		if !post.RunLongNameTest(startID, parallelCount) {
+			os.Exit(1)
+		}
+	case "leak", "l":
+		fmt.Println("実行モード: コネクションリークテスト")
+		if !post.RunLeakTest(startID, parallelCount) {
+			os.Exit(1)
+		}
 	default:
 		fmt.Printf("未知のテストモード: %s\n", testMode)
 		fmt.Println("使用方法: go run ./client [開始ID] [並列数] [テストモード] [追加パラメータ...]")
 		fmt.Println("テストモード:")
+		fmt.Println("  normal, n: 通常のロック取得・解放テスト")
 		fmt.Println("  hold, h: ロック保持・解放テスト [保持時間(秒)]")
 		fmt.Println("  process, p: 商品ロックテスト")
 		fmt.Println("  order, o: 注文ロックテスト")
+		fmt.Println("  decrement, d: 在庫減算テスト（並列に在庫を減らし、在庫数が0未満にならないことを確認）")
+		fmt.Println("  checkout, c: 複数商品の注文テスト（在庫を超える注文が明細ごとの理由付きで拒否されることを確認）")
+		fmt.Println("  rw, r: 読み取り・書き込みロックテスト（注文一覧の読み取りが同時に実行され、注文の挿入が読み取りを待つことを確認）")
+		fmt.Println("  semaphore, s: セマフォテスト [許可の数]（同時に許可を保持するクライアントが許可の数以下であることを確認）")
+		fmt.Println("  fair, f: 公平モードのロックテスト（順番待ちの番号の順にロックを取得することを確認）")
+		fmt.Println("  try, t: 待機なしのロックテスト（取得できなかったクライアントはすぐに諦める）")
+		fmt.Println("  longname, ln: 複合ロック名のテスト（'/' を含む64文字を超えるロック名で、取得・状態と所有者の確認・解放が成功することを確認）")
+		fmt.Println("  leak, l: コネクションリークテスト（タイムアウト後に接続がプールに戻ることを確認）")
 		os.Exit(1)
 	}
 }
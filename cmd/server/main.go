@@ -11,6 +11,8 @@ import (
 	"github.com/example/named-lock/internal/config"
 	"github.com/example/named-lock/internal/db"
 	"github.com/example/named-lock/internal/handler"
+	"github.com/example/named-lock/internal/locker"
+	"github.com/example/named-lock/internal/scheduler"
 	"github.com/example/named-lock/internal/service"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
@@ -28,27 +30,55 @@ func main() {
 	})
 
 	// データベース接続を登録
-	do.Provide(injector, func(i *do.Injector) (*db.DB, error) {
-		dbCfg := do.MustInvoke[*config.Config](i).DB
-		return db.NewDB(&dbCfg)
-	})
+	// 保持・解放のバックエンドがMySQLでない場合は、MySQLに接続できなくても起動する（接続していない場合は nil）
+	do.Provide(injector, locker.NewDatabase)
+
+	// ロックのバックエンドを登録（設定の LockerConfig.HoldReleaseBackend で選択する）
+	do.Provide(injector, locker.NewBackend)
+
+	// 定期実行ジョブのスケジューラーを登録
+	do.Provide(injector, scheduler.NewScheduler)
 
 	// サービスを登録
 	do.Provide(injector, service.NewLockService)
+	do.Provide(injector, service.NewAdminService)
 
 	// ハンドラを登録
+	do.Provide(injector, handler.NewRequestValidator)
 	do.Provide(injector, handler.NewLockHandler)
+	do.Provide(injector, handler.NewAdminHandler)
+
+	// データベースに接続する（MySQLのバックエンドで接続できない場合は起動しない）
+	database := do.MustInvoke[*db.DB](injector)
 
 	// Echoインスタンスを作成
 	e := echo.New()
 
+	// エラーレスポンスを共通の形式で返す
+	e.HTTPErrorHandler = handler.HTTPErrorHandler
+
+	// リクエストの検証を設定
+	e.Validator = do.MustInvoke[*handler.RequestValidator](injector)
+
 	// ミドルウェアを設定
+	e.Use(middleware.RequestID())
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
+	// '/' を含むロック名をパスパラメータで指定できるよう、エスケープされたパスパラメータをデコードする
+	e.Use(handler.UnescapePathParams)
 
 	// ハンドラを取得してルートを登録
 	lockHandler := do.MustInvoke[*handler.LockHandler](injector)
 	lockHandler.RegisterRoutes(e)
+	adminHandler := do.MustInvoke[*handler.AdminHandler](injector)
+	adminHandler.RegisterRoutes(e)
+
+	// 定期実行ジョブを開始する（同じジョブは、ジョブのロックを取得できたひとつのサーバーだけが実行する）
+	jobScheduler := do.MustInvoke[*scheduler.Scheduler](injector)
+	// ジョブのロックと実行履歴はデータベースを使うため、データベースに接続していない場合は開始しない
+	if cfg.Scheduler.Enabled && database != nil {
+		jobScheduler.Start()
+	}
 
 	// サーバーを起動
 	go func() {
@@ -70,10 +100,18 @@ func main() {
 		log.Fatalf("Server forced to shutdown: %v\n", err)
 	}
 
+	// 定期実行ジョブを停止する（実行中のジョブの終了を待つ）
+	jobScheduler.Stop()
+
+	// 保持中のリースを解放する
+	lockService := do.MustInvoke[*service.LockService](injector)
+	lockService.Close()
+
 	// データベース接続を閉じる
-	database := do.MustInvoke[*db.DB](injector)
-	if err := database.Close(); err != nil {
-		log.Printf("Error closing database connection: %v\n", err)
+	if database != nil {
+		if err := database.Close(); err != nil {
+			log.Printf("Error closing database connection: %v\n", err)
+		}
 	}
 
 	log.Println("Server exiting")
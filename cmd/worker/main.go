@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/example/named-lock/internal/config"
+	"github.com/example/named-lock/internal/db"
+	"github.com/example/named-lock/internal/election"
+)
+
+// campaignRetryInterval は立候補に失敗した場合に、立候補し直すまでの間隔
+const campaignRetryInterval = 1 * time.Second
+
+func main() {
+	hostname, _ := os.Hostname()
+	// 選出の名前（同じ名前のワーカーのうち、ひとつだけがリーダーとして動作する）
+	name := flag.String("name", "worker", "リーダー選出の名前")
+	candidateID := flag.String("id", fmt.Sprintf("%s-%d", hostname, os.Getpid()), "候補者ID")
+	workInterval := flag.Duration("interval", 2*time.Second, "リーダーとして処理を実行する間隔")
+	flag.Parse()
+
+	cfg := config.NewConfig()
+	database, err := db.NewDB(&cfg.DB)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer database.Close()
+
+	// シグナルを受け取ったら辞任して終了する
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	elector := election.NewElector(database, &cfg.Election)
+	go printEvents(elector.Observe(ctx, *name))
+
+	for ctx.Err() == nil {
+		log.Printf("Campaigning for %s as %s", *name, *candidateID)
+		leadership, err := elector.Campaign(ctx, *name, *candidateID)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			log.Printf("Failed to campaign: %v", err)
+			time.Sleep(campaignRetryInterval)
+			continue
+		}
+
+		lead(ctx, leadership, *workInterval)
+		// 地位を失った場合も、接続を片付けるために辞任する
+		if err := leadership.Resign(context.Background()); err != nil && !errors.Is(err, db.ErrLockNotHeld) {
+			log.Printf("Failed to resign: %v", err)
+		}
+	}
+	log.Println("Worker exiting")
+}
+
+// lead はリーダーの地位を失うか、コンテキストが終了するまで、一定の間隔で処理を実行する
+func lead(ctx context.Context, leadership *election.Leadership, interval time.Duration) {
+	leader := leadership.Leader()
+	workCtx, cancel := leadership.WithContext(ctx)
+	defer cancel()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-workCtx.Done():
+			if err := context.Cause(workCtx); errors.Is(err, db.ErrLockLost) {
+				log.Printf("Lost leadership (term: %d): %v", leader.Term, err)
+			}
+			return
+		case <-ticker.C:
+			log.Printf("Working as leader %s (term: %d, session ID: %d)", leader.CandidateID, leader.Term, leader.SessionID)
+		}
+	}
+}
+
+// printEvents はリーダーの変化を表示する
+func printEvents(events <-chan election.Event) {
+	for event := range events {
+		switch event.Type {
+		case election.EventElected:
+			log.Printf("[%s] leader elected: %s (term: %d, session ID: %d)", event.Name, event.Leader.CandidateID, event.Leader.Term, event.Leader.SessionID)
+		default:
+			log.Printf("[%s] leader %s: %s (term: %d, session ID: %d)", event.Name, event.Type, event.Previous.CandidateID, event.Previous.Term, event.Previous.SessionID)
+		}
+	}
+}
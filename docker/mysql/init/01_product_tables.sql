@@ -3,5 +3,7 @@ CREATE TABLE IF NOT EXISTS products (
   code VARCHAR(50) PRIMARY KEY,
   quantity INT NOT NULL DEFAULT 0,
   created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  -- 在庫数が0未満にならないようにする（MySQL 8.0.16以降で有効）
+  CONSTRAINT chk_products_quantity CHECK (quantity >= 0)
 );
@@ -2,6 +2,7 @@
 CREATE TABLE IF NOT EXISTS orders (
   id VARCHAR(50) PRIMARY KEY,
   code VARCHAR(50) DEFAULT NULL,
+  quantity INT NOT NULL DEFAULT 0,
   created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
   updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
 );
\ No newline at end of file
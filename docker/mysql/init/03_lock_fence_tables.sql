@@ -0,0 +1,7 @@
+-- フェンシングトークンテーブル（ロック名ごとに最後に発行したトークン）
+CREATE TABLE IF NOT EXISTS lock_fences (
+  lock_name VARCHAR(64) PRIMARY KEY,
+  token BIGINT UNSIGNED NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
@@ -0,0 +1,2 @@
+-- 管理用の権限（名前付きロックの一覧で performance_schema.metadata_locks と threads を参照する）
+GRANT SELECT ON performance_schema.* TO 'user'@'%';
@@ -0,0 +1,17 @@
+-- 管理操作の記録テーブル（名前付きロックの強制解放）
+CREATE TABLE IF NOT EXISTS lock_admin_actions (
+  id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+  lock_name VARCHAR(255) NOT NULL,
+  lock_key VARCHAR(64) NOT NULL,
+  connection_id BIGINT UNSIGNED NOT NULL DEFAULT 0,
+  owner_user VARCHAR(32) NOT NULL DEFAULT '',
+  owner_host VARCHAR(255) NOT NULL DEFAULT '',
+  action VARCHAR(32) NOT NULL,
+  dry_run BOOLEAN NOT NULL,
+  reason VARCHAR(1024) NOT NULL,
+  result VARCHAR(32) NOT NULL,
+  error TEXT,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  INDEX idx_lock_admin_actions_lock_name (lock_name, created_at)
+);
@@ -0,0 +1,8 @@
+-- 順番待ちテーブル（セマフォの許可などの取得の順番待ち）
+CREATE TABLE IF NOT EXISTS lock_queue (
+  id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+  lock_name VARCHAR(64) NOT NULL,
+  connection_id BIGINT UNSIGNED NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  INDEX idx_lock_queue_lock_name (lock_name, id)
+);
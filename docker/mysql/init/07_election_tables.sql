@@ -0,0 +1,10 @@
+-- リーダー選出のメタデータテーブル（選出の名前ごとに最後に選ばれたリーダー）
+-- 行はリーダーが辞任するまで残るため、リーダーであるかは IS_USED_LOCK の結果と合わせて判断する
+CREATE TABLE IF NOT EXISTS election_leaders (
+  name VARCHAR(255) PRIMARY KEY,
+  candidate_id VARCHAR(255) NOT NULL,
+  connection_id BIGINT UNSIGNED NOT NULL,
+  term BIGINT UNSIGNED NOT NULL,
+  elected_at TIMESTAMP(6) NOT NULL,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
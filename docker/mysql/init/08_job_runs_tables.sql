@@ -0,0 +1,16 @@
+-- 定期実行ジョブの実行記録テーブル
+-- 同じジョブの同じ予定時刻の実行はひとつだけ記録する（複数のサーバーで重複して実行しないため）
+CREATE TABLE IF NOT EXISTS job_runs (
+  id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+  job_name VARCHAR(255) NOT NULL,
+  scheduled_at TIMESTAMP(6) NOT NULL,
+  started_at TIMESTAMP(6) NOT NULL,
+  finished_at TIMESTAMP(6) NULL,
+  duration_ms BIGINT UNSIGNED NULL,
+  outcome VARCHAR(16) NOT NULL,
+  connection_id BIGINT UNSIGNED NOT NULL,
+  instance VARCHAR(255) NOT NULL DEFAULT '',
+  message TEXT,
+  UNIQUE KEY uk_job_runs_schedule (job_name, scheduled_at),
+  INDEX idx_job_runs_started_at (job_name, started_at)
+);
@@ -0,0 +1,12 @@
+-- 既存のボリュームの注文テーブルに明細の数量（quantity 列）を追加する
+-- 新しいボリュームでは init/02_order_tables.sql で作成済みのため何もしない（何度実行してもよい）
+SET @has_quantity = (
+  SELECT COUNT(*) FROM information_schema.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'orders' AND COLUMN_NAME = 'quantity'
+);
+SET @ddl = IF(@has_quantity = 0,
+  'ALTER TABLE orders ADD COLUMN quantity INT NOT NULL DEFAULT 0 AFTER code',
+  'DO 0');
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
@@ -0,0 +1,30 @@
+-- 既存のボリュームの商品テーブルに、在庫数が0未満にならない CHECK 制約を追加する
+-- 新しいボリュームでは init/01_product_tables.sql で作成済みのため何もしない（何度実行してもよい）
+-- 在庫数が0未満の行がある場合は、データを書き換えずに該当する行を表示して失敗する
+-- （在庫数を確認・修正してから再度実行する）
+DROP PROCEDURE IF EXISTS add_products_quantity_check;
+
+DELIMITER //
+CREATE PROCEDURE add_products_quantity_check()
+BEGIN
+  DECLARE negative_rows INT;
+  DECLARE message VARCHAR(128);
+
+  SELECT COUNT(*) INTO negative_rows FROM products WHERE quantity < 0;
+  IF negative_rows > 0 THEN
+    SELECT code, quantity FROM products WHERE quantity < 0 ORDER BY code;
+    SET message = CONCAT(negative_rows, ' products have quantity < 0; fix them before adding chk_products_quantity');
+    SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = message;
+  END IF;
+
+  IF NOT EXISTS (
+    SELECT 1 FROM information_schema.TABLE_CONSTRAINTS
+    WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'products' AND CONSTRAINT_NAME = 'chk_products_quantity'
+  ) THEN
+    ALTER TABLE products ADD CONSTRAINT chk_products_quantity CHECK (quantity >= 0);
+  END IF;
+END//
+DELIMITER ;
+
+CALL add_products_quantity_check();
+DROP PROCEDURE add_products_quantity_check;
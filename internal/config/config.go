@@ -1,8 +1,20 @@
 package config
 
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
 // Config はアプリケーション設定を保持する構造体
 type Config struct {
-	DB DBConfig
+	DB         DBConfig
+	Lock       LockConfig
+	Validation ValidationConfig
+	Admin      AdminConfig
+	Election   ElectionConfig
+	Scheduler  SchedulerConfig
+	Locker     LockerConfig
 }
 
 // DBConfig はデータベース接続設定を保持する構造体
@@ -13,6 +25,82 @@ type DBConfig struct {
 	User     string
 	Password string
 	DBName   string
+	// LockNamespace は名前付きロックの名前に前置する名前空間（同じMySQLを使う他のアプリケーションと区別する）
+	LockNamespace string
+	// LockHeartbeatInterval は保持しているロックを失っていないか確認する間隔（0以下の場合は確認しない）
+	LockHeartbeatInterval time.Duration
+	// RWLockStripes は読み取り・書き込みロックのストライプ数（同時に読み取りロックを保持できるセッション数の上限）
+	RWLockStripes int
+}
+
+// LockConfig はロックの保持に関する設定を保持する構造体
+type LockConfig struct {
+	// DefaultLeaseTTL はTTL未指定でロックを取得した場合のリース期間
+	DefaultLeaseTTL time.Duration
+	// MaxLeaseTTL は取得・延長時に指定できるリース期間の上限
+	MaxLeaseTTL time.Duration
+	// ExpiredHistorySize は状態一覧に残す期限切れリースの件数
+	ExpiredHistorySize int
+}
+
+// ValidationConfig はリクエストの検証に関する設定を保持する構造体
+type ValidationConfig struct {
+	// MaxLockNameLength はロック名の最大文字数
+	// MySQLの制限（64文字）を超える名前はDB層でハッシュ化されるため、64文字より大きくできる
+	MaxLockNameLength int
+	// LockNamePattern はロック名に使用できる文字を表す正規表現
+	LockNamePattern string
+	// MaxWaitTimeout はロック待ちのタイムアウト（秒）の上限
+	MaxWaitTimeout int
+	// AllowInfiniteWait はタイムアウトに負の値（無期限に待つ）を許可するか
+	AllowInfiniteWait bool
+	// MaxHoldDuration はロックの保持時間（秒）の上限
+	MaxHoldDuration int
+	// MaxCheckoutLines は一度の注文で指定できる明細の上限
+	MaxCheckoutLines int
+	// MaxSemaphorePermits はセマフォの許可の数の上限（許可の数だけスロットの名前付きロックを使う）
+	MaxSemaphorePermits int
+}
+
+// AdminConfig は管理用APIに関する設定を保持する構造体
+type AdminConfig struct {
+	// Token は管理用APIの認証に使用するトークン（Authorization: Bearer <token>）
+	// 空の場合は管理用APIを使用できない
+	Token string
+}
+
+// ElectionConfig はリーダー選出に関する設定を保持する構造体
+type ElectionConfig struct {
+	// ObserveInterval はリーダーの変化を確認する間隔
+	ObserveInterval time.Duration
+}
+
+// SchedulerConfig は定期実行ジョブに関する設定を保持する構造体
+// 実行予定は @every <間隔>、@hourly、@daily、または cron 形式（分 時 日 月 曜日）で指定する
+type SchedulerConfig struct {
+	// Enabled はサーバーで定期実行ジョブを実行するか
+	Enabled bool
+	// JobTimeout はひとつの実行の制限時間
+	JobTimeout time.Duration
+	// ReconcileInventorySpec は在庫の照合の実行予定（空の場合は登録しない）
+	ReconcileInventorySpec string
+	// PurgeOrdersSpec は古い注文の削除の実行予定（空の場合は登録しない）
+	PurgeOrdersSpec string
+	// OrderRetention は注文を残す期間（これより古い注文を削除する）
+	OrderRetention time.Duration
+	// PurgeBatchSize は古い注文を一度に削除する件数
+	PurgeBatchSize int
+}
+
+// LockerConfig はロックのバックエンドに関する設定を保持する構造体
+type LockerConfig struct {
+	// HoldReleaseBackend はロックのみを扱う保持・解放（公平モードを除く hold-and-release）で使うバックエンド（mysql、memory、file）
+	// memory はプロセス内、file は flock でホスト内のロックになる
+	// その他の処理はロックを取得した接続のトランザクションやMySQLのテーブルを使うため、常にMySQLの名前付きロックを使う
+	// mysql 以外の場合は、MySQLに接続できなくても起動する（その他の処理は使用できない）
+	HoldReleaseBackend string
+	// FileDir は file バックエンドのロックファイルを作成するディレクトリ
+	FileDir string
 }
 
 // NewConfig は新しい設定インスタンスを作成する
@@ -25,8 +113,54 @@ func NewConfig() *Config {
 			User:     "user",
 			Password: "password",
 			DBName:   "locktest",
+			// 既存のロック名（test_lock など）と互換性を保つため、既定では名前空間を付けない
+			LockNamespace:         "",
+			LockHeartbeatInterval: 1 * time.Second,
+			RWLockStripes:         8,
+		},
+		Lock: LockConfig{
+			DefaultLeaseTTL:    30 * time.Second,
+			MaxLeaseTTL:        10 * time.Minute,
+			ExpiredHistorySize: 100,
+		},
+		Validation: ValidationConfig{
+			MaxLockNameLength:   255,
+			LockNamePattern:     `^[A-Za-z0-9_.:/-]+$`,
+			MaxWaitTimeout:      300,
+			AllowInfiniteWait:   true,
+			MaxHoldDuration:     600,
+			MaxCheckoutLines:    50,
+			MaxSemaphorePermits: 64,
+		},
+		Admin: AdminConfig{
+			// トークンはソースコードに含めず、環境変数から読み込む
+			Token: os.Getenv("NAMED_LOCK_ADMIN_TOKEN"),
+		},
+		Election: ElectionConfig{
+			ObserveInterval: 1 * time.Second,
 		},
+		Scheduler: SchedulerConfig{
+			Enabled:                true,
+			JobTimeout:             5 * time.Minute,
+			ReconcileInventorySpec: "@every 10m",
+			PurgeOrdersSpec:        "0 3 * * *",
+			OrderRetention:         30 * 24 * time.Hour,
+			PurgeBatchSize:         1000,
+		},
+		Locker: LockerConfig{
+			// 環境変数 NAMED_LOCK_HOLD_RELEASE_BACKEND で切り替えられる
+			HoldReleaseBackend: getEnv("NAMED_LOCK_HOLD_RELEASE_BACKEND", "mysql"),
+			FileDir:            filepath.Join(os.TempDir(), "named-lock"),
+		},
+	}
+}
+
+// getEnv は環境変数の値を返す（設定されていない場合は fallback を返す）
+func getEnv(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
 	}
+	return fallback
 }
 
 // GetDSN はデータベース接続文字列を返す
@@ -3,8 +3,10 @@ package db
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/example/named-lock/internal/config"
 	_ "github.com/go-sql-driver/mysql"
@@ -13,11 +15,31 @@ import (
 // DB はデータベース操作を行うための構造体
 type DB struct {
 	*sql.DB
+	// namer はロック名をMySQLのロック名（名前空間付き、長い場合はハッシュ化）に変換する
+	namer *lockNamer
+	// guard はロックを保持したままの接続がプールに戻るのを防ぐ
+	guard *lockGuard
+	// heartbeatInterval は保持しているロックを失っていないか確認する間隔
+	heartbeatInterval time.Duration
+	// held はこのプロセスで保持中のロックのハンドル
+	held *heldLocks
+	// rwStripes は読み取り・書き込みロックのストライプ数
+	rwStripes int
 }
 
 // Tx はトランザクションを表す構造体
 type Tx struct {
 	*sql.Tx
+	// ctx はトランザクションを開始したコンテキスト（キャンセルされるとトランザクションはロールバックされる）
+	ctx context.Context
+	// fences はロック名ごとのフェンシングトークン（ロックを保持している接続のトランザクションのみ）
+	fences map[string]fence
+}
+
+// Context はトランザクションを開始したコンテキストを返す
+// ロックを保持している接続のトランザクションでは、ロックを失った時点でキャンセルされる
+func (tx *Tx) Context() context.Context {
+	return tx.ctx
 }
 
 // Conn はデータベース接続を表す構造体
@@ -37,8 +59,20 @@ func NewDB(cfg *config.DBConfig) (*DB, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
+	rwStripes := cfg.RWLockStripes
+	if rwStripes <= 0 {
+		rwStripes = 1
+	}
+
 	log.Println("Connected to database successfully")
-	return &DB{DB: db}, nil
+	return &DB{
+		DB:                db,
+		namer:             newLockNamer(cfg.LockNamespace),
+		guard:             &lockGuard{},
+		heartbeatInterval: cfg.LockHeartbeatInterval,
+		held:              &heldLocks{locks: make(map[*Lock]struct{})},
+		rwStripes:         rwStripes,
+	}, nil
 }
 
 // Close はデータベース接続を閉じる
@@ -56,21 +90,39 @@ func (db *DB) GetCurrentConnectionID() (int64, error) {
 	return id, nil
 }
 
-// GetNamedLock は名前付きロックを取得する
-// lockName: ロック名
-// timeout: タイムアウト（秒）
-// 戻り値: 1=ロック取得成功, 0=ロック取得失敗, error=エラー
-func (db *DB) GetNamedLock(ctx context.Context, lockName string, timeout int) (*Conn, bool, error) {
-	conn, err := db.Conn(ctx)
-	if err != nil {
-		return nil, false, fmt.Errorf("failed to begin transaction: %w", err)
-	}
-	var result bool
-	err = conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", lockName, timeout).Scan(&result)
-	if err != nil {
-		return nil, false, fmt.Errorf("failed to get lock: %w", err)
-	}
-	return &Conn{Conn: conn}, result, nil
+// LockStatus は名前付きロックの状態を表す構造体
+type LockStatus struct {
+	LockName string
+	// OwnerSessionID はロックを保持しているセッションID（未使用の場合は0）
+	OwnerSessionID int64
+	// CurrentSessionID は状態を問い合わせたセッションID
+	CurrentSessionID int64
+}
+
+// IsLocked はロックがいずれかのセッションに保持されているかを返す
+func (s *LockStatus) IsLocked() bool {
+	return s.OwnerSessionID != 0
+}
+
+// IsOwnedByCurrentSession は問い合わせたセッション自身がロックを保持しているかを返す
+func (s *LockStatus) IsOwnedByCurrentSession() bool {
+	return s.IsLocked() && s.OwnerSessionID == s.CurrentSessionID
+}
+
+// GetLockStatus はプールから取得した接続で名前付きロックの状態を取得する
+func (db *DB) GetLockStatus(ctx context.Context, lockName string) (*LockStatus, error) {
+	return scanLockStatus(db.QueryRowContext(ctx, "SELECT IS_USED_LOCK(?), CONNECTION_ID()", db.LockKey(lockName)), lockName)
+}
+
+// LockKey はロック名に対応するMySQLのロック名を返す
+// 設定された名前空間を前置し、64文字を超える場合は先頭部分を残してハッシュ化する
+func (db *DB) LockKey(lockName string) string {
+	return db.namer.key(lockName)
+}
+
+// OriginalLockName はMySQLのロック名から元のロック名を返す（ログや状態表示に使用する）
+func (db *DB) OriginalLockName(key string) string {
+	return db.namer.original(key)
 }
 
 // BeginTx はトランザクションを開始する
@@ -80,44 +132,169 @@ func (db *DB) BeginTx(ctx context.Context) (*Tx, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	return &Tx{Tx: tx}, nil
+	return &Tx{Tx: tx, ctx: ctx}, nil
+}
+
+// BeginTx はこの接続（セッション）でトランザクションを開始する
+// ロックを取得した接続と同じセッションでデータを更新する場合に使用する
+func (conn *Conn) BeginTx(ctx context.Context) (*Tx, error) {
+	tx, err := conn.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	return &Tx{Tx: tx, ctx: ctx}, nil
+}
+
+// WithNamedLock は名前付きロックを取得し、ロックを保持している接続でトランザクションを実行する
+// fnが成功した場合はコミットしてからロックを解放し、失敗した場合はロールバックしてからロックを解放する
+// コミット前にロックが解放されることはないため、ロック区間外で更新が見える状態にはならない
+// timeout: ロック待ちのタイムアウト（秒）。負の値の場合は無期限に待つ
+func (db *DB) WithNamedLock(ctx context.Context, lockName string, timeout int, fn func(tx *Tx) error) error {
+	// ロックを取得（タイムアウトはロック待ちにのみ適用する）
+	waitCtx, cancel := LockWaitContext(ctx, timeout)
+	lock, err := db.AcquireLock(waitCtx, lockName)
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	return withLockTx(ctx, lock, fn)
+}
+
+// WithFairNamedLock は順番待ち（公平モード）で名前付きロックを取得し、ロックを保持している接続でトランザクションを実行する
+// 戻り値の QueueStats は順番待ちの統計（順番待ちに並べなかった場合は nil）
+// timeout: 順番待ちとロック待ちにまとめて適用するタイムアウト（秒）。負の値の場合は無期限に待つ
+func (db *DB) WithFairNamedLock(ctx context.Context, lockName string, timeout int, fn func(tx *Tx) error) (*QueueStats, error) {
+	// ロックを取得（タイムアウトはロック待ちにのみ適用する）
+	waitCtx, cancel := LockWaitContext(ctx, timeout)
+	lock, stats, err := db.AcquireFair(waitCtx, lockName)
+	cancel()
+	if err != nil {
+		return stats, err
+	}
+
+	return stats, withLockTx(ctx, lock, fn)
+}
+
+// WithNamedLocks は複数の名前付きロックをひとつのセッションで取得し、その接続でトランザクションを実行する
+// ロックは AcquireMany で名前順に取得し、いずれかを取得できなかった場合は取得済みのロックを解放してエラーを返す
+// timeout: すべてのロックの取得にまとめて適用する待機のタイムアウト（秒）。負の場合は無期限に待つ
+func (db *DB) WithNamedLocks(ctx context.Context, lockNames []string, timeout int, fn func(tx *Tx) error) error {
+	// ロックを取得（タイムアウトはロック待ちにのみ適用する）
+	waitCtx, cancel := LockWaitContext(ctx, timeout)
+	lock, err := db.AcquireMany(waitCtx, lockNames)
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	return withLockTx(ctx, lock, fn)
+}
+
+// WithReadLock は読み取りロック（共有ロック）を取得し、ロックを保持している接続でトランザクションを実行する
+// 同じロック名の読み取りロックは、ストライプ数まで同時に保持できる
+// timeout: ロック待ちのタイムアウト（秒）。負の値の場合は無期限に待つ
+func (db *DB) WithReadLock(ctx context.Context, lockName string, timeout int, fn func(tx *Tx) error) error {
+	// ロックを取得（タイムアウトはロック待ちにのみ適用する）
+	waitCtx, cancel := LockWaitContext(ctx, timeout)
+	lock, err := db.AcquireRead(waitCtx, lockName)
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	return withLockTx(ctx, lock, fn)
+}
+
+// WithWriteLock は書き込みロック（排他ロック）を取得し、ロックを保持している接続でトランザクションを実行する
+// 書き込みロックは、同じロック名の読み取りロック・書き込みロック・名前付きロック（WithNamedLock）のいずれとも同時に保持されない
+// timeout: ロック待ちのタイムアウト（秒）。負の値の場合は無期限に待つ
+func (db *DB) WithWriteLock(ctx context.Context, lockName string, timeout int, fn func(tx *Tx) error) error {
+	// ロックを取得（タイムアウトはロック待ちにのみ適用する）
+	waitCtx, cancel := LockWaitContext(ctx, timeout)
+	lock, err := db.AcquireWrite(waitCtx, lockName)
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	return withLockTx(ctx, lock, fn)
 }
 
-// GetNamedLock は名前付きロックを取得する
-// lockName: ロック名
-// timeout: タイムアウト（秒）
-// 戻り値: 1=ロック取得成功, 0=ロック取得失敗, error=エラー
-func (tx *Tx) GetNamedLock(lockName string, timeout int) (bool, error) {
-	var result bool
-	err := tx.QueryRow("SELECT GET_LOCK(?, ?)", lockName, timeout).Scan(&result)
+// withLockTx はロックを保持している接続でトランザクションを実行し、終了後にロックを解放する
+// ロックを失った場合はトランザクションをロールバックし、ErrLockLost を含むエラーを返す
+func withLockTx(ctx context.Context, lock *Lock, fn func(tx *Tx) error) (err error) {
+	// コミット・ロールバックの後にロックを解放する
+	// リクエストがキャンセルされていても解放できるよう、キャンセルを引き継がないコンテキストを使う
+	defer func() {
+		releaseErr := lock.Release(context.WithoutCancel(ctx))
+		if err == nil {
+			err = releaseErr
+		}
+	}()
+
+	// ロックを保持している接続でトランザクションを開始
+	// ロックを失った時点でコンテキストがキャンセルされ、保護されていない更新がコミットされないようにする
+	txCtx, cancel := lock.WithContext(ctx)
+	defer cancel()
+	tx, err := lock.BeginTx(txCtx)
 	if err != nil {
-		return false, fmt.Errorf("failed to get lock: %w", err)
+		return lock.lostError(err)
 	}
-	return result, nil
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return lock.lostError(err)
+	}
+
+	// トランザクションをコミット
+	if err := tx.Commit(); err != nil {
+		return lock.lostError(fmt.Errorf("failed to commit transaction: %w", err))
+	}
+	return nil
 }
 
 // ReleaseNamedLock は名前付きロックを解放する
-// lockName: ロック名
-// 戻り値: 1=ロック解放成功, 0=ロックが存在しないか他のセッションが所有, error=エラー
+// lockName: MySQLのロック名（DB.LockKey で変換済みの名前）
+// 戻り値: true=ロック解放成功, false=ロックが存在しない（NULL）か他のセッションが所有（0）, error=エラー
 func (conn *Conn) ReleaseNamedLock(ctx context.Context, lockName string) (bool, error) {
-	var result bool
+	var result sql.NullInt64
 	err := conn.QueryRowContext(ctx, "SELECT RELEASE_LOCK(?)", lockName).Scan(&result)
 	if err != nil {
 		return false, fmt.Errorf("failed to release lock: %w", err)
 	}
-	return result, nil
+	return result.Valid && result.Int64 == 1, nil
 }
 
-// ReleaseNamedLock は名前付きロックを解放する
-// lockName: ロック名
-// 戻り値: 1=ロック解放成功, 0=ロックが存在しないか他のセッションが所有, error=エラー
-func (tx *Tx) ReleaseNamedLock(lockName string) (bool, error) {
-	var result bool
-	err := tx.QueryRow("SELECT RELEASE_LOCK(?)", lockName).Scan(&result)
+// GetLockStatus はこの接続（セッション）で名前付きロックの状態を取得する
+// lockKey: MySQLのロック名（DB.LockKey で変換済みの名前）
+// lockName: 結果に設定する元のロック名
+func (conn *Conn) GetLockStatus(ctx context.Context, lockKey string, lockName string) (*LockStatus, error) {
+	return scanLockStatus(conn.QueryRowContext(ctx, "SELECT IS_USED_LOCK(?), CONNECTION_ID()", lockKey), lockName)
+}
+
+// GetCurrentConnectionID はこの接続のセッションIDを取得する
+func (conn *Conn) GetCurrentConnectionID(ctx context.Context) (int64, error) {
+	var id int64
+	err := conn.QueryRowContext(ctx, "SELECT CONNECTION_ID()").Scan(&id)
 	if err != nil {
-		return false, fmt.Errorf("failed to release lock: %w", err)
+		return 0, fmt.Errorf("failed to get connection id: %w", err)
 	}
-	return result, nil
+	return id, nil
+}
+
+// scanLockStatus は IS_USED_LOCK と CONNECTION_ID の結果を LockStatus に変換する
+func scanLockStatus(row *sql.Row, lockName string) (*LockStatus, error) {
+	var owner sql.NullInt64
+	var current int64
+	if err := row.Scan(&owner, &current); err != nil {
+		return nil, fmt.Errorf("failed to get lock status: %w", err)
+	}
+	return &LockStatus{
+		LockName:         lockName,
+		OwnerSessionID:   owner.Int64,
+		CurrentSessionID: current,
+	}, nil
 }
 
 // GetCurrentConnectionID は現在の接続のセッションIDを取得する
@@ -156,8 +333,34 @@ func (tx *Tx) GetProductForUpdate(productCode string) (*Product, error) {
 	return &product, nil
 }
 
+// GetProduct は商品コードから商品情報を取得する（ロックを取得せずに現在の値を読む）
+// 商品が存在しない場合は nil を返す
+func (db *DB) GetProduct(ctx context.Context, productCode string) (*Product, error) {
+	var product Product
+
+	query := `
+		SELECT code, quantity
+		FROM products
+		WHERE code = ?`
+	err := db.QueryRowContext(ctx, query, productCode).Scan(&product.Code, &product.Quantity)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+
+	return &product, nil
+}
+
 // UpdateInventory は在庫情報を更新する
+// 在庫数が0未満になる場合はテーブルの CHECK 制約で拒否され、ErrNegativeStock を返す
+// 商品コードのロックのフェンシングトークンが古い場合は更新せず、ErrStaleFence を返す
 func (tx *Tx) UpdateInventory(product *Product) error {
+	if err := tx.checkFence(product.Code); err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE products 
 		SET quantity = ?
@@ -165,6 +368,9 @@ func (tx *Tx) UpdateInventory(product *Product) error {
 
 	_, err := tx.Exec(query, product.Quantity, product.Code)
 	if err != nil {
+		if isCheckConstraintViolation(err) {
+			return fmt.Errorf("failed to update inventory: %w: %w", ErrNegativeStock, err)
+		}
 		return fmt.Errorf("failed to update inventory: %w", err)
 	}
 
@@ -172,7 +378,12 @@ func (tx *Tx) UpdateInventory(product *Product) error {
 }
 
 // InsertInventory は新しい在庫情報を挿入する
+// 商品コードのロックのフェンシングトークンが古い場合は挿入せず、ErrStaleFence を返す
 func (tx *Tx) InsertInventory(product *Product) error {
+	if err := tx.checkFence(product.Code); err != nil {
+		return err
+	}
+
 	query := `
 		INSERT INTO products 
 		(code, quantity) 
@@ -187,15 +398,16 @@ func (tx *Tx) InsertInventory(product *Product) error {
 
 // Order は注文情報を表す構造体
 type Order struct {
-	ID   string
-	Code string
+	ID       string
+	Code     string
+	Quantity int
 }
 
 func (tx *Tx) ListOrderByCode(code string) ([]*Order, error) {
 	var orders []*Order
 
 	query := `
-		SELECT id, code
+		SELECT id, code, quantity
 		FROM orders 
 		WHERE code = ?`
 
@@ -206,7 +418,7 @@ func (tx *Tx) ListOrderByCode(code string) ([]*Order, error) {
 	defer rows.Close()
 	for rows.Next() {
 		var order Order
-		if err := rows.Scan(&order.ID, &order.Code); err != nil {
+		if err := rows.Scan(&order.ID, &order.Code, &order.Quantity); err != nil {
 			return nil, fmt.Errorf("failed to scan order: %w", err)
 		}
 		orders = append(orders, &order)
@@ -218,13 +430,18 @@ func (tx *Tx) ListOrderByCode(code string) ([]*Order, error) {
 }
 
 // InsertOrder は新しい注文情報を挿入する
+// 商品コードのロックのフェンシングトークンが古い場合は挿入せず、ErrStaleFence を返す
 func (tx *Tx) InsertOrder(order *Order) error {
+	if err := tx.checkFence(order.Code); err != nil {
+		return err
+	}
+
 	query := `
 		INSERT INTO orders 
-		(id, code) 
-		VALUES (?, ?)`
+		(id, code, quantity) 
+		VALUES (?, ?, ?)`
 
-	_, err := tx.Exec(query, order.ID, order.Code)
+	_, err := tx.Exec(query, order.ID, order.Code, order.Quantity)
 	if err != nil {
 		return fmt.Errorf("failed to insert order: %w", err)
 	}
@@ -0,0 +1,82 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ElectionLeader はリーダー選出のメタデータ（election_leaders テーブル）を表す構造体
+type ElectionLeader struct {
+	// Name は選出の名前
+	Name        string
+	CandidateID string
+	// ConnectionID はリーダーのロックを保持している接続のセッションID
+	ConnectionID int64
+	// Term はリーダーの任期（リーダーのロックのフェンシングトークン。選ばれるたびに増える）
+	Term      int64
+	ElectedAt time.Time
+}
+
+// ElectionLockName はリーダー選出の名前に対応するロック名を返す
+func ElectionLockName(name string) string {
+	return DerivedLockName(name, "leader")
+}
+
+// UpsertElectionLeader はリーダーのロックを保持している接続のトランザクションで、リーダーのメタデータを書き込む
+// リーダーのロックのフェンシングトークンが古い場合（ロックを失った後に次のリーダーが選ばれた）は書き込まず、ErrStaleFence を返す
+func (tx *Tx) UpsertElectionLeader(leader *ElectionLeader) error {
+	if err := tx.checkFence(ElectionLockName(leader.Name)); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO election_leaders
+		(name, candidate_id, connection_id, term, elected_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			candidate_id = VALUES(candidate_id),
+			connection_id = VALUES(connection_id),
+			term = VALUES(term),
+			elected_at = VALUES(elected_at)`
+	_, err := tx.Exec(query, leader.Name, leader.CandidateID, leader.ConnectionID, leader.Term, leader.ElectedAt)
+	if err != nil {
+		return fmt.Errorf("failed to upsert election leader: %w", err)
+	}
+	return nil
+}
+
+// DeleteElectionLeader はリーダーが辞任する際に、自分の任期のメタデータを削除する
+// 既に次のリーダーが書き込んでいる場合（任期が異なる場合）は削除しない
+func (tx *Tx) DeleteElectionLeader(name string, term int64) error {
+	if err := tx.checkFence(ElectionLockName(name)); err != nil {
+		return err
+	}
+
+	_, err := tx.Exec("DELETE FROM election_leaders WHERE name = ? AND term = ?", name, term)
+	if err != nil {
+		return fmt.Errorf("failed to delete election leader: %w", err)
+	}
+	return nil
+}
+
+// GetElectionLeader は最後に選ばれたリーダーのメタデータを返す（行がない場合は nil）
+// リーダーが辞任せずに接続が切断された場合も行は残るため、現在のリーダーかは IS_USED_LOCK と合わせて判断する
+func (db *DB) GetElectionLeader(ctx context.Context, name string) (*ElectionLeader, error) {
+	query := `
+		SELECT name, candidate_id, connection_id, term, elected_at
+		FROM election_leaders
+		WHERE name = ?`
+
+	var leader ElectionLeader
+	err := db.QueryRowContext(ctx, query, name).Scan(&leader.Name, &leader.CandidateID, &leader.ConnectionID, &leader.Term, &leader.ElectedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get election leader: %w", err)
+	}
+	return &leader, nil
+}
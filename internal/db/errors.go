@@ -0,0 +1,139 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// MySQLのエラー番号
+const (
+	// erQueryInterrupted は KILL QUERY などでクエリが中断された場合のエラー
+	erQueryInterrupted = 1317
+	// erUserLockWrongName はロック名が不正な場合のエラー
+	erUserLockWrongName = 3057
+	// erUserLockDeadlock は名前付きロック同士のデッドロックを検出した場合のエラー
+	erUserLockDeadlock = 3058
+	// erCheckConstraintViolated は CHECK 制約に違反した場合のエラー
+	erCheckConstraintViolated = 3819
+	// erDupEntry は一意キーが重複した場合のエラー
+	erDupEntry = 1062
+)
+
+// ロック操作のエラー（errors.Is で判定する）
+var (
+	// ErrLockTimeout はロック待ちがタイムアウトした（GET_LOCK が 0 を返した）ことを表す
+	ErrLockTimeout = errors.New("lock wait timeout")
+	// ErrLockDeadlock はロック待ちがデッドロックとして打ち切られたことを表す（ER_USER_LOCK_DEADLOCK）
+	ErrLockDeadlock = errors.New("lock deadlock detected")
+	// ErrLockNameInvalid はロック名が不正であることを表す（ER_USER_LOCK_WRONG_NAME）
+	ErrLockNameInvalid = errors.New("invalid lock name")
+	// ErrLockNotHeld は解放しようとしたロックをこのセッションが保持していないことを表す
+	ErrLockNotHeld = errors.New("lock not held by this session")
+	// ErrLockKilled はロック待ちが中断された（GET_LOCK が NULL を返した、またはKILLされた）ことを表す
+	ErrLockKilled = errors.New("lock wait killed")
+	// ErrLockLost は保持していたロックを、セッションの切断などで失ったことを表す
+	ErrLockLost = errors.New("lock lost")
+)
+
+// ErrNegativeStock は在庫数を0未満に更新しようとしたことを表す（products テーブルの CHECK 制約）
+var ErrNegativeStock = errors.New("product quantity must not be negative")
+
+// ErrStaleFence は書き込みが持つフェンシングトークンが、最後に発行されたトークンより古いことを表す
+// ロックを失った後に他のセッションがロックを取得しているため、書き込みを拒否した
+var ErrStaleFence = errors.New("stale fencing token")
+
+// isCheckConstraintViolation は CHECK 制約違反のエラーかどうかを返す
+func isCheckConstraintViolation(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == erCheckConstraintViolated
+}
+
+// isDuplicateEntry は一意キーの重複のエラーかどうかを返す
+func isDuplicateEntry(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == erDupEntry
+}
+
+// LockError はロック操作のエラーを表す構造体
+// Err は上記のロック操作エラー、またはデータベースのエラーを保持する
+type LockError struct {
+	// Op は失敗した操作（acquire, release, hold）
+	Op string
+	// LockName は対象のロック名
+	LockName string
+	// Err はエラーの種類（ErrLockTimeout など。分類できない場合はデータベースのエラー）
+	Err error
+	// Cause はエラーの原因となったデータベースやコンテキストのエラー（存在する場合）
+	Cause error
+}
+
+// Error はエラーメッセージを返す
+func (e *LockError) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("failed to %s lock %q: %v: %v", e.Op, e.LockName, e.Err, e.Cause)
+	}
+	return fmt.Sprintf("failed to %s lock %q: %v", e.Op, e.LockName, e.Err)
+}
+
+// Unwrap は errors.Is / errors.As で判定できるよう、エラーの種類と原因を返す
+func (e *LockError) Unwrap() []error {
+	if e.Cause != nil {
+		return []error{e.Err, e.Cause}
+	}
+	return []error{e.Err}
+}
+
+// IsRetryable はロック操作を再試行すれば成功する可能性があるエラーかどうかを返す
+// デッドロックとして打ち切られた場合は、取得済みのロックが解放されているため最初からやり直せる
+func IsRetryable(err error) bool {
+	return errors.Is(err, ErrLockDeadlock)
+}
+
+// newLockError はデータベースやコンテキストのエラーを分類して LockError を作成する
+func newLockError(op string, lockName string, err error) *LockError {
+	kind, ok := classifyLockError(err)
+	if !ok {
+		// 分類できないエラーはデータベースのエラーとしてそのまま保持する
+		return &LockError{Op: op, LockName: lockName, Err: err}
+	}
+	lockErr := &LockError{Op: op, LockName: lockName, Err: kind}
+	if err != kind {
+		lockErr.Cause = err
+	}
+	return lockErr
+}
+
+// classifyLockError はエラーをロック操作エラーに分類する
+// 分類できない場合は false を返す
+func classifyLockError(err error) (error, bool) {
+	for _, kind := range []error{ErrLockTimeout, ErrLockDeadlock, ErrLockNameInvalid, ErrLockNotHeld, ErrLockKilled, ErrLockLost} {
+		if errors.Is(err, kind) {
+			return kind, true
+		}
+	}
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		// 期限切れで待機を打ち切った場合はタイムアウトとして扱う
+		return ErrLockTimeout, true
+	case errors.Is(err, context.Canceled):
+		// リクエストのキャンセルで待機を打ち切った場合
+		return ErrLockKilled, true
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case erUserLockDeadlock:
+			return ErrLockDeadlock, true
+		case erUserLockWrongName:
+			return ErrLockNameInvalid, true
+		case erQueryInterrupted:
+			return ErrLockKilled, true
+		}
+	}
+	return nil, false
+}
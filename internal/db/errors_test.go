@@ -0,0 +1,100 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestClassifyLockError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		want   error
+		wantOk bool
+	}{
+		{"timeout", ErrLockTimeout, ErrLockTimeout, true},
+		{"wrapped kind", fmt.Errorf("failed to acquire: %w", ErrLockNotHeld), ErrLockNotHeld, true},
+		{"lock error", &LockError{Op: "release", LockName: "a", Err: ErrLockNotHeld}, ErrLockNotHeld, true},
+		{"lost", &LockError{Op: "hold", LockName: "a", Err: ErrLockLost}, ErrLockLost, true},
+		{"deadline exceeded", context.DeadlineExceeded, ErrLockTimeout, true},
+		{"canceled", fmt.Errorf("failed to wait: %w", context.Canceled), ErrLockKilled, true},
+		{"mysql deadlock", &mysql.MySQLError{Number: erUserLockDeadlock}, ErrLockDeadlock, true},
+		{"mysql wrong name", &mysql.MySQLError{Number: erUserLockWrongName}, ErrLockNameInvalid, true},
+		{"mysql interrupted", &mysql.MySQLError{Number: erQueryInterrupted}, ErrLockKilled, true},
+		// ロック以外の MySQL のエラー（ER_NO_SUCH_TABLE）は分類しない
+		{"other mysql error", &mysql.MySQLError{Number: 1146}, nil, false},
+		{"other error", errors.New("connection refused"), nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := classifyLockError(tt.err)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("classifyLockError(%v) = %v, %t; want %v, %t", tt.err, got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestNewLockError(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		wantErr   error
+		wantCause error
+	}{
+		// 分類したエラーそのものの場合は Cause を持たない
+		{"kind", ErrLockTimeout, ErrLockTimeout, nil},
+		{"context", context.Canceled, ErrLockKilled, context.Canceled},
+		{"mysql", &mysql.MySQLError{Number: erUserLockDeadlock}, ErrLockDeadlock, &mysql.MySQLError{Number: erUserLockDeadlock}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lockErr := newLockError("acquire", "product123", tt.err)
+			if lockErr.Op != "acquire" || lockErr.LockName != "product123" {
+				t.Errorf("op/name = %s/%s, want acquire/product123", lockErr.Op, lockErr.LockName)
+			}
+			if lockErr.Err != tt.wantErr {
+				t.Errorf("Err = %v, want %v", lockErr.Err, tt.wantErr)
+			}
+			if fmt.Sprint(lockErr.Cause) != fmt.Sprint(tt.wantCause) {
+				t.Errorf("Cause = %v, want %v", lockErr.Cause, tt.wantCause)
+			}
+			if !errors.Is(lockErr, tt.wantErr) {
+				t.Errorf("errors.Is(%v, %v) = false", lockErr, tt.wantErr)
+			}
+		})
+	}
+
+	// 分類できないエラーはそのまま Err に保持する
+	dbErr := errors.New("connection refused")
+	lockErr := newLockError("release", "product123", dbErr)
+	if lockErr.Err != dbErr || lockErr.Cause != nil {
+		t.Errorf("unclassified error = %+v, want Err %v without Cause", lockErr, dbErr)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"deadlock", newLockError("acquire", "a", &mysql.MySQLError{Number: erUserLockDeadlock}), true},
+		{"wrapped deadlock", fmt.Errorf("failed to checkout: %w", &LockError{Op: "acquire", LockName: "a", Err: ErrLockDeadlock}), true},
+		{"timeout", &LockError{Op: "acquire", LockName: "a", Err: ErrLockTimeout}, false},
+		{"killed", newLockError("acquire", "a", context.Canceled), false},
+		{"stale fence", ErrStaleFence, false},
+		{"nil", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable(%v) = %t, want %t", tt.err, got, tt.want)
+			}
+		})
+	}
+}
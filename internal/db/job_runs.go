@@ -0,0 +1,188 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// 定期実行ジョブの実行結果（JobRun.Outcome）
+const (
+	// JobOutcomeRunning は実行中（終了時に結果で更新する。サーバーが停止した場合はこのまま残る）
+	JobOutcomeRunning   = "running"
+	JobOutcomeSucceeded = "succeeded"
+	JobOutcomeFailed    = "failed"
+)
+
+// ErrJobRunExists は同じジョブの同じ予定時刻の実行が既に記録されていることを表す
+// 他のサーバーが先に実行したため、実行しない
+var ErrJobRunExists = errors.New("job run already recorded for the scheduled time")
+
+// JobRun は定期実行ジョブの実行記録（job_runs テーブル）を表す構造体
+type JobRun struct {
+	ID          int64
+	JobName     string
+	ScheduledAt time.Time
+	StartedAt   time.Time
+	// FinishedAt と Duration は終了した場合のみ設定する
+	FinishedAt *time.Time
+	Duration   time.Duration
+	Outcome    string
+	// ConnectionID はジョブのロックを保持していた接続のセッションID
+	ConnectionID int64
+	// Instance は実行したサーバー（ホスト名とプロセスID）
+	Instance string
+	// Message は実行結果の概要、または失敗した場合のエラー
+	Message string
+}
+
+// InsertJobRun はジョブの実行の開始を job_runs テーブルに記録し、記録のIDを返す
+// 同じジョブの同じ予定時刻の実行が既に記録されている場合は ErrJobRunExists を返す
+func (db *DB) InsertJobRun(ctx context.Context, run *JobRun) (int64, error) {
+	query := `
+		INSERT INTO job_runs
+		(job_name, scheduled_at, started_at, outcome, connection_id, instance, message)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`
+	result, err := db.ExecContext(ctx, query,
+		run.JobName, run.ScheduledAt, run.StartedAt, run.Outcome, run.ConnectionID, run.Instance, run.Message)
+	if err != nil {
+		if isDuplicateEntry(err) {
+			return 0, fmt.Errorf("failed to insert job run: %w", ErrJobRunExists)
+		}
+		return 0, fmt.Errorf("failed to insert job run: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get job run ID: %w", err)
+	}
+	return id, nil
+}
+
+// FinishJobRun は記録済みのジョブの実行を、終了時刻・実行時間・結果で更新する
+func (db *DB) FinishJobRun(ctx context.Context, id int64, finishedAt time.Time, duration time.Duration, outcome string, message string) error {
+	query := `
+		UPDATE job_runs
+		SET finished_at = ?, duration_ms = ?, outcome = ?, message = ?
+		WHERE id = ?`
+	if _, err := db.ExecContext(ctx, query, finishedAt, duration.Milliseconds(), outcome, message, id); err != nil {
+		return fmt.Errorf("failed to update job run: %w", err)
+	}
+	return nil
+}
+
+// ListJobRuns はジョブの実行記録を新しい順に最大 limit 件返す
+func (db *DB) ListJobRuns(ctx context.Context, jobName string, limit int) ([]*JobRun, error) {
+	query := `
+		SELECT id, job_name, scheduled_at, started_at, finished_at, duration_ms, outcome, connection_id, instance, message
+		FROM job_runs
+		WHERE job_name = ?
+		ORDER BY started_at DESC, id DESC
+		LIMIT ?`
+	return db.queryJobRuns(ctx, query, jobName, limit)
+}
+
+// LatestJobRuns はジョブごとの最後の実行記録を返す（ジョブ名 → 記録。実行記録がないジョブは含まない）
+func (db *DB) LatestJobRuns(ctx context.Context) (map[string]*JobRun, error) {
+	query := `
+		SELECT id, job_name, scheduled_at, started_at, finished_at, duration_ms, outcome, connection_id, instance, message
+		FROM job_runs
+		WHERE id IN (SELECT MAX(id) FROM job_runs GROUP BY job_name)`
+	runs, err := db.queryJobRuns(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	latest := make(map[string]*JobRun, len(runs))
+	for _, run := range runs {
+		latest[run.JobName] = run
+	}
+	return latest, nil
+}
+
+// queryJobRuns は job_runs テーブルの行を読み取る
+func (db *DB) queryJobRuns(ctx context.Context, query string, args ...interface{}) ([]*JobRun, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list job runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []*JobRun
+	for rows.Next() {
+		var run JobRun
+		var finishedAt sql.NullTime
+		var durationMs sql.NullInt64
+		var message sql.NullString
+		if err := rows.Scan(&run.ID, &run.JobName, &run.ScheduledAt, &run.StartedAt, &finishedAt, &durationMs,
+			&run.Outcome, &run.ConnectionID, &run.Instance, &message); err != nil {
+			return nil, fmt.Errorf("failed to scan job run: %w", err)
+		}
+		if finishedAt.Valid {
+			run.FinishedAt = &finishedAt.Time
+		}
+		run.Duration = time.Duration(durationMs.Int64) * time.Millisecond
+		run.Message = message.String
+		runs = append(runs, &run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over job runs: %w", err)
+	}
+	return runs, nil
+}
+
+// PurgeOrders は created_at が before より古い注文を最大 limit 件削除し、削除した件数を返す
+// 一度に大量の行をロックしないよう、呼び出し元は削除した件数が limit 未満になるまで繰り返す
+func (db *DB) PurgeOrders(ctx context.Context, before time.Time, limit int) (int64, error) {
+	result, err := db.ExecContext(ctx, "DELETE FROM orders WHERE created_at < ? ORDER BY created_at LIMIT ?", before, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge orders: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get purged order count: %w", err)
+	}
+	return deleted, nil
+}
+
+// InventorySummary は在庫の照合結果を表す構造体
+type InventorySummary struct {
+	// Products は商品の数
+	Products int
+	// OutOfStock は在庫が0の商品の数
+	OutOfStock int
+	// OrphanOrderCodes は商品テーブルに存在しない商品コードの注文の商品コード
+	OrphanOrderCodes []string
+}
+
+// ReconcileInventory は商品と注文を照合し、在庫切れの商品と、存在しない商品の注文を集計する
+func (db *DB) ReconcileInventory(ctx context.Context) (*InventorySummary, error) {
+	var summary InventorySummary
+	query := "SELECT COUNT(*), COALESCE(SUM(quantity = 0), 0) FROM products"
+	if err := db.QueryRowContext(ctx, query).Scan(&summary.Products, &summary.OutOfStock); err != nil {
+		return nil, fmt.Errorf("failed to summarize products: %w", err)
+	}
+
+	query = `
+		SELECT DISTINCT o.code
+		FROM orders o
+		LEFT JOIN products p ON p.code = o.code
+		WHERE o.code IS NOT NULL AND p.code IS NULL
+		ORDER BY o.code`
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find orphan orders: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var code string
+		if err := rows.Scan(&code); err != nil {
+			return nil, fmt.Errorf("failed to scan orphan order: %w", err)
+		}
+		summary.OrphanOrderCodes = append(summary.OrphanOrderCodes, code)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over orphan orders: %w", err)
+	}
+	return &summary, nil
+}
@@ -0,0 +1,503 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// Lock は取得済みの名前付きロックを表す構造体
+// ロックを取得した接続（セッション）を保持し、Release でロックを解放して接続をプールに戻す
+// AcquireLock はロックを取得できた場合にのみ Lock を返すため、呼び出し元が接続を閉じ忘れることはない
+// AcquireMany で取得した場合は、ひとつのセッションで複数のロックを保持する
+// MySQLの名前付きロックはセッション内で再入可能（同じ名前を取得した回数だけ RELEASE_LOCK が必要）なため、
+// ロック名ごとに取得回数を数え、解放時に同じ回数だけ解放する
+type Lock struct {
+	db *DB
+	// names はロック名（最初に取得した順）、keys は対応するMySQLのロック名
+	names []string
+	keys  []string
+	// holds はロック名ごとの取得回数（0の場合は保持していない）
+	holds map[string]int
+	// tokens はロック名ごとのフェンシングトークン（取得回数が0から1になった時点で発行する）
+	tokens map[string]int64
+	// mu は names, keys, holds をハートビートと共有するためのロック（変更時とハートビートの読み取り時に取得する）
+	mu     sync.Mutex
+	connID int64
+	conn   *Conn
+	// released はハンドルを閉じた（接続をプールに戻した、または破棄した）か
+	// ハンドルの状態は他のゴルーチンから確認されることがあるため atomic.Bool にする
+	released atomic.Bool
+
+	// lostCtx はロックを失ったことを検知した時点でキャンセルされる（原因は ErrLockLost）
+	lostCtx  context.Context
+	markLost context.CancelCauseFunc
+	// stopHeartbeat はハートビートを停止する（解放・破棄時に呼び出す）
+	stopHeartbeat func()
+}
+
+// newLock は names のロックを1回ずつ取得した接続のハンドルを作成する
+// tokens: names に対応するフェンシングトークン
+func (db *DB) newLock(conn *sql.Conn, connID int64, names []string, keys []string, tokens []int64) *Lock {
+	holds := make(map[string]int, len(names))
+	tokenByName := make(map[string]int64, len(names))
+	for i, name := range names {
+		holds[name] = 1
+		tokenByName[name] = tokens[i]
+	}
+	lostCtx, markLost := context.WithCancelCause(context.Background())
+	l := &Lock{
+		db:       db,
+		names:    names,
+		keys:     keys,
+		holds:    holds,
+		tokens:   tokenByName,
+		connID:   connID,
+		conn:     &Conn{Conn: conn},
+		lostCtx:  lostCtx,
+		markLost: markLost,
+	}
+	l.startHeartbeat(db.heartbeatInterval)
+	db.held.add(l)
+	return l
+}
+
+// AcquireLock は名前付きロックを取得し、ロックを保持している接続のハンドルを返す
+// ロック待ちのタイムアウトはコンテキストの期限から求める（期限がない場合は無期限に待つ）
+// 待機中にコンテキストがキャンセルされた場合は GET_LOCK を KILL QUERY で打ち切る
+// ロックを取得できなかった場合は、どの経路でも接続をプールに戻すか破棄してから *LockError を返す
+func (db *DB) AcquireLock(ctx context.Context, lockName string) (*Lock, error) {
+	lock, acquired, err := db.getLock(ctx, lockName, lockWaitSeconds(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if !acquired {
+		return nil, newLockError("acquire", lockName, ErrLockTimeout)
+	}
+	return lock, nil
+}
+
+// TryLock は待機せずに名前付きロックの取得を試みる（GET_LOCK(name, 0)）
+// 他のセッションが保持している場合はエラーではなく false を返す
+func (db *DB) TryLock(ctx context.Context, lockName string) (*Lock, bool, error) {
+	return db.getLock(ctx, lockName, 0)
+}
+
+// AcquireMany は複数の名前付きロックをひとつのセッションで取得し、そのハンドルを返す
+// ロック名は重複を除いて名前順に並べ替えてから取得するため、AcquireMany 同士でデッドロックすることはない
+// ロック待ちのタイムアウトはコンテキストの期限から求め、すべてのロックの取得にまとめて適用する
+// いずれかのロックを取得できなかった場合は、取得済みのロックをすべて解放してから *LockError を返す
+func (db *DB) AcquireMany(ctx context.Context, lockNames []string) (*Lock, error) {
+	names := sortLockNames(lockNames)
+	if len(names) == 0 {
+		return nil, errors.New("no lock names specified")
+	}
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return nil, newLockError("acquire", names[0], ctx.Err())
+	}
+
+	conn, connID, err := db.lockConn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(names))
+	for _, name := range names {
+		key := db.LockKey(name)
+		acquired, err := db.waitNamedLock(ctx, conn, connID, key, lockWaitSeconds(ctx))
+		if err != nil {
+			// 接続は waitNamedLock で破棄済みのため、取得済みのロックも解放されている
+			return nil, newLockError("acquire", name, err)
+		}
+		if !acquired {
+			db.releasePartialLocks(conn, connID, names[:len(keys)], keys)
+			return nil, newLockError("acquire", name, ErrLockTimeout)
+		}
+		keys = append(keys, key)
+	}
+
+	// すべてのロックを取得してから、フェンシングトークンを発行する
+	tokens, err := issueFenceTokens(context.WithoutCancel(ctx), conn, keys)
+	if err != nil {
+		db.releasePartialLocks(conn, connID, names, keys)
+		return nil, newLockError("acquire", names[0], err)
+	}
+
+	return db.newLock(conn, connID, names, keys, tokens), nil
+}
+
+// sortLockNames はロック名の重複を除き、名前順に並べ替えた新しいスライスを返す
+func sortLockNames(lockNames []string) []string {
+	names := slices.Clone(lockNames)
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// issueFenceTokens は keys のロックのフェンシングトークンを順に発行する
+func issueFenceTokens(ctx context.Context, conn *sql.Conn, keys []string) ([]int64, error) {
+	tokens := make([]int64, 0, len(keys))
+	for _, key := range keys {
+		token, err := issueFenceToken(ctx, conn, key)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, nil
+}
+
+// releasePartialLocks は途中まで取得したロックを解放し、接続をプールに戻す
+// 解放できなかった場合は、ロックを保持したまま再利用されないよう接続を破棄する
+func (db *DB) releasePartialLocks(conn *sql.Conn, connID int64, names []string, keys []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), killTimeout)
+	defer cancel()
+
+	for _, key := range keys {
+		var result sql.NullInt64
+		if err := conn.QueryRowContext(ctx, "SELECT RELEASE_LOCK(?)", key).Scan(&result); err != nil || result.Int64 != 1 {
+			discardConn(conn)
+			return
+		}
+	}
+	db.returnConn(ctx, conn, connID, names)
+}
+
+// lockConn はロックの取得に使用する接続と、そのセッションIDを返す
+func (db *DB) lockConn(ctx context.Context) (*sql.Conn, int64, error) {
+	// 期限切れのコンテキストでも待機なし（GET_LOCK(name, 0)）で試せるよう、
+	// 接続の準備にはキャンセルを伝えない
+	setupCtx := context.WithoutCancel(ctx)
+	conn, err := db.Conn(setupCtx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get connection: %w", err)
+	}
+	var connID int64
+	if err := conn.QueryRowContext(setupCtx, "SELECT CONNECTION_ID()").Scan(&connID); err != nil {
+		conn.Close()
+		return nil, 0, fmt.Errorf("failed to get connection id: %w", err)
+	}
+	return conn, connID, nil
+}
+
+// getLock は新しい接続で GET_LOCK を実行する
+// wait: GET_LOCK に渡すタイムアウト（秒）
+// 取得できなかった場合は接続をプールに戻すか破棄し、Lock は返さない
+func (db *DB) getLock(ctx context.Context, lockName string, wait int) (*Lock, bool, error) {
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return nil, false, newLockError("acquire", lockName, ctx.Err())
+	}
+
+	conn, connID, err := db.lockConn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	key := db.LockKey(lockName)
+	acquired, err := db.waitNamedLock(ctx, conn, connID, key, wait)
+	if err != nil {
+		// 接続は waitNamedLock で破棄済み
+		return nil, false, newLockError("acquire", lockName, err)
+	}
+	if !acquired {
+		// ロックを取得していないため、接続はプールに戻す
+		db.returnConn(ctx, conn, connID, nil)
+		return nil, false, nil
+	}
+
+	lock, err := db.newAcquiredLock(ctx, conn, connID, lockName, key)
+	if err != nil {
+		return nil, false, err
+	}
+	return lock, true, nil
+}
+
+// newAcquiredLock はロックをひとつ取得した接続で、フェンシングトークンを発行してハンドルを作成する
+// トークンを発行できなかった場合は、ロックを解放して接続をプールに戻す
+func (db *DB) newAcquiredLock(ctx context.Context, conn *sql.Conn, connID int64, lockName string, key string) (*Lock, error) {
+	token, err := issueFenceToken(context.WithoutCancel(ctx), conn, key)
+	if err != nil {
+		db.releasePartialLocks(conn, connID, []string{lockName}, []string{key})
+		return nil, newLockError("acquire", lockName, err)
+	}
+
+	return db.newLock(conn, connID, []string{lockName}, []string{key}, []int64{token}), nil
+}
+
+// IsFreeLock は名前付きロックがどのセッションにも保持されていないかを返す（IS_FREE_LOCK）
+func (db *DB) IsFreeLock(ctx context.Context, lockName string) (bool, error) {
+	var result sql.NullInt64
+	if err := db.QueryRowContext(ctx, "SELECT IS_FREE_LOCK(?)", db.LockKey(lockName)).Scan(&result); err != nil {
+		return false, newLockError("check", lockName, err)
+	}
+	if !result.Valid {
+		return false, newLockError("check", lockName, errors.New("IS_FREE_LOCK returned NULL"))
+	}
+	return result.Int64 == 1, nil
+}
+
+// IsUsedLock は名前付きロックを保持しているセッションIDを返す（IS_USED_LOCK）
+// 保持しているセッションがない場合は false を返す
+func (db *DB) IsUsedLock(ctx context.Context, lockName string) (int64, bool, error) {
+	var owner sql.NullInt64
+	if err := db.QueryRowContext(ctx, "SELECT IS_USED_LOCK(?)", db.LockKey(lockName)).Scan(&owner); err != nil {
+		return 0, false, newLockError("check", lockName, err)
+	}
+	return owner.Int64, owner.Valid, nil
+}
+
+// Closed はハンドルが解放済みか（Release などで接続をプールに戻したか、エラーで接続を破棄したか）を返す
+// 接続を破棄した場合、そのセッションが保持していたロックはすべて解放されている
+func (l *Lock) Closed() bool {
+	return l.released.Load()
+}
+
+// primary は代表のロック（保持しているロックのうち最初に取得したもの）の添字を返す
+// 読み取りロックのゲートのように途中で解放したロックは、代表にしない
+// すべて解放済みの場合は最初に取得したロックを返す
+func (l *Lock) primary() int {
+	for i, name := range l.names {
+		if l.holds[name] > 0 {
+			return i
+		}
+	}
+	return 0
+}
+
+// Name はロック名を返す（複数のロックを保持している場合は保持しているうち最初に取得したロック名）
+func (l *Lock) Name() string {
+	return l.names[l.primary()]
+}
+
+// Names は保持しているすべてのロック名を最初に取得した順に返す
+func (l *Lock) Names() []string {
+	names := make([]string, 0, len(l.names))
+	for _, name := range l.names {
+		if l.holds[name] > 0 {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Key はMySQLのロック名（名前空間付き、長い場合はハッシュ化した名前）を返す
+// 複数のロックを保持している場合は保持しているうち最初に取得したロックのものを返す
+func (l *Lock) Key() string {
+	return l.keys[l.primary()]
+}
+
+// ConnectionID はロックを保持しているセッションIDを返す
+func (l *Lock) ConnectionID() int64 {
+	return l.connID
+}
+
+// FenceToken はロックのフェンシングトークンを返す（複数のロックを保持している場合は保持しているうち最初に取得したロックのもの）
+// トークンはロック名ごとに取得のたびに増えるため、書き込み先はより新しいトークンを見たら古いトークンの書き込みを拒否できる
+func (l *Lock) FenceToken() int64 {
+	return l.tokens[l.names[l.primary()]]
+}
+
+// FenceTokenFor はロック名のフェンシングトークンを返す（保持していない場合は0）
+func (l *Lock) FenceTokenFor(lockName string) int64 {
+	if l.holds[lockName] == 0 {
+		return 0
+	}
+	return l.tokens[lockName]
+}
+
+// HoldCount はロック名を取得した回数（解放までに必要な RELEASE_LOCK の回数）を返す
+// 保持していない場合は0を返す
+func (l *Lock) HoldCount(lockName string) int {
+	return l.holds[lockName]
+}
+
+// BeginTx はロックを保持している接続でトランザクションを開始する
+// トランザクションは保持しているロックのフェンシングトークンを持ち、商品・注文の書き込み時に確認する
+func (l *Lock) BeginTx(ctx context.Context) (*Tx, error) {
+	tx, err := l.conn.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tx.fences = make(map[string]fence, len(l.names))
+	for i, name := range l.names {
+		if l.holds[name] > 0 {
+			tx.fences[name] = fence{key: l.keys[i], token: l.tokens[name]}
+		}
+	}
+	return tx, nil
+}
+
+// Status はロックを保持している接続でロックの状態を取得する（複数の場合は Name のロック）
+func (l *Lock) Status(ctx context.Context) (*LockStatus, error) {
+	i := l.primary()
+	return l.conn.GetLockStatus(ctx, l.keys[i], l.names[i])
+}
+
+// Acquire はロックを保持している接続で、さらに名前付きロックを取得する
+// 既に保持しているロック名の場合は取得回数が増え（再入）、その回数だけ解放が必要になる
+// ロック待ちのタイムアウトはコンテキストの期限から求める
+// ロック待ちが中断された場合やデッドロックの場合は接続を破棄するため、保持していたすべてのロックを失う
+func (l *Lock) Acquire(ctx context.Context, lockName string) error {
+	acquired, err := l.acquire(ctx, lockName, lockWaitSeconds(ctx))
+	if err != nil {
+		return err
+	}
+	if !acquired {
+		return newLockError("acquire", lockName, ErrLockTimeout)
+	}
+	return nil
+}
+
+// TryAcquire はロックを保持している接続で、待機せずにさらに名前付きロックの取得を試みる（GET_LOCK(name, 0)）
+// 他のセッションが保持している場合はエラーではなく false を返す
+func (l *Lock) TryAcquire(ctx context.Context, lockName string) (bool, error) {
+	return l.acquire(ctx, lockName, 0)
+}
+
+// acquire はロックを保持している接続で GET_LOCK を実行し、取得できた場合は取得回数を増やす
+// wait: GET_LOCK に渡すタイムアウト（秒）
+func (l *Lock) acquire(ctx context.Context, lockName string, wait int) (bool, error) {
+	if l.released.Load() {
+		return false, newLockError("acquire", lockName, ErrLockNotHeld)
+	}
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return false, newLockError("acquire", lockName, ctx.Err())
+	}
+
+	key := l.db.LockKey(lockName)
+	acquired, err := l.db.waitNamedLock(ctx, l.conn.Conn, l.connID, key, wait)
+	if err != nil {
+		// 接続は waitNamedLock で破棄済み
+		l.finish()
+		return false, newLockError("acquire", lockName, err)
+	}
+	if !acquired {
+		return false, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.holds[lockName] == 0 {
+		// 新たに取得した（再入ではない）場合は、フェンシングトークンを発行する
+		token, err := issueFenceToken(context.WithoutCancel(ctx), l.conn.Conn, key)
+		if err != nil {
+			if _, releaseErr := l.conn.ReleaseNamedLock(context.WithoutCancel(ctx), key); releaseErr != nil {
+				l.discard()
+			}
+			return false, newLockError("acquire", lockName, err)
+		}
+		l.tokens[lockName] = token
+	}
+	if _, ok := l.holds[lockName]; !ok {
+		l.names = append(l.names, lockName)
+		l.keys = append(l.keys, key)
+	}
+	l.holds[lockName]++
+	return true, nil
+}
+
+// ReleaseOne はロック名を1回だけ解放する（取得回数を1減らす）
+// 取得回数が0になってもハンドルと接続は保持したままのため、最後に Release または ReleaseAll を呼び出す
+func (l *Lock) ReleaseOne(ctx context.Context, lockName string) error {
+	if l.released.Load() || l.holds[lockName] == 0 {
+		return newLockError("release", lockName, ErrLockNotHeld)
+	}
+
+	// ハートビートが解放中のロックを失ったと誤検知しないよう、解放と取得回数の更新を l.mu の中で行う
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	i := slices.Index(l.names, lockName)
+	result, err := l.conn.ReleaseNamedLock(ctx, l.keys[i])
+	if err != nil {
+		l.discard()
+		return newLockError("release", lockName, err)
+	}
+	if !result {
+		// セッションが切断されるなどして、既にロックを失っている
+		l.discard()
+		return newLockError("release", lockName, ErrLockNotHeld)
+	}
+	l.holds[lockName]--
+	return nil
+}
+
+// Release は保持しているすべてのロックを取得と逆の順に、取得した回数だけ解放し、接続をプールに戻す
+// 解放に失敗した場合は、ロックを保持したまま再利用されないよう接続を破棄する
+func (l *Lock) Release(ctx context.Context) error {
+	if l.released.Load() {
+		return nil
+	}
+
+	for i := len(l.names) - 1; i >= 0; i-- {
+		for l.holds[l.names[i]] > 0 {
+			if err := l.ReleaseOne(ctx, l.names[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return l.close(ctx)
+}
+
+// ReleaseAll はこのセッションが保持しているすべての名前付きロックを RELEASE_ALL_LOCKS() でまとめて解放し、
+// 接続をプールに戻す。戻り値は解放したロックの数（再入した回数を含む）
+// ハンドルが数えていないロック（同じ接続のトランザクションで直接取得したロックなど）も解放される
+func (l *Lock) ReleaseAll(ctx context.Context) (int, error) {
+	if l.released.Load() {
+		return 0, nil
+	}
+
+	// ハートビートが解放中のロックを失ったと誤検知しないよう、解放と取得回数の更新を l.mu の中で行う
+	l.mu.Lock()
+	var released int
+	if err := l.conn.QueryRowContext(ctx, "SELECT RELEASE_ALL_LOCKS()").Scan(&released); err != nil {
+		l.discard()
+		l.mu.Unlock()
+		return 0, newLockError("release", l.names[0], err)
+	}
+	expected := 0
+	for _, count := range l.holds {
+		expected += count
+	}
+	clear(l.holds)
+	l.mu.Unlock()
+
+	if released != expected {
+		log.Printf("Warning: RELEASE_ALL_LOCKS released %d locks, but the handle held %d (connection ID: %d, locks: %v)", released, expected, l.connID, l.names)
+	}
+
+	// ロックはすべて解放されているため、接続はプールに戻してよい
+	if err := l.close(ctx); err != nil {
+		return released, err
+	}
+	if released < expected {
+		// ハンドルが数えていた取得の一部を、解放する前に既に失っていた
+		return released, newLockError("release", l.names[0], ErrLockNotHeld)
+	}
+	return released, nil
+}
+
+// close は接続をプールに戻す
+// ハンドルが数えずに残っているロック（同じ接続のトランザクションで直接取得したロックなど）は、
+// プールに戻す前に解放するか、解放できない場合は接続を破棄する
+func (l *Lock) close(ctx context.Context) error {
+	l.finish()
+	return l.db.returnConn(ctx, l.conn.Conn, l.connID, l.names)
+}
+
+// discard はロックの状態が不明な接続をプールに戻さずに破棄する
+func (l *Lock) discard() {
+	l.finish()
+	discardConn(l.conn.Conn)
+}
+
+// finish はハンドルを解放済みにし、ハートビートを停止する（保持中のロックの一覧からも除く）
+func (l *Lock) finish() {
+	l.released.Store(true)
+	l.stopHeartbeat()
+	l.db.held.remove(l)
+}
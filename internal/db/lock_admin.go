@@ -0,0 +1,147 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// 管理操作の種類（AdminAction.Action）
+const (
+	// AdminActionKillConnection はロックを保持しているセッションの接続を切断する（ロックは解放される）
+	AdminActionKillConnection = "kill_connection"
+	// AdminActionKillQuery はロックを保持しているセッションで実行中のクエリのみを打ち切る（ロックは解放されない）
+	AdminActionKillQuery = "kill_query"
+)
+
+// 管理操作の結果（AdminAction.Result）
+const (
+	// AdminResultPending は KILL を発行する前に記録した状態（KILL の後に結果で更新する）
+	AdminResultPending = "pending"
+	AdminResultKilled  = "killed"
+	AdminResultDryRun  = "dry_run"
+	AdminResultNotHeld = "not_held"
+	AdminResultRefused = "refused"
+	AdminResultFailed  = "failed"
+)
+
+// ErrForeignConnection はロックを保持している接続が、アプリケーションのユーザーのものでないことを表す
+// 他のアプリケーションや管理者のセッションを誤って切断しないために、強制解放を拒否する
+var ErrForeignConnection = errors.New("lock owner connection does not belong to the application user")
+
+// LockOwner は名前付きロックを保持しているセッションを表す構造体
+type LockOwner struct {
+	LockName string
+	// Key はMySQLのロック名
+	Key     string
+	Session LockSession
+	// Visible は information_schema.PROCESSLIST でセッションを参照できたか
+	// PROCESS 権限がない場合、他のユーザーのセッションは参照できない
+	Visible bool
+}
+
+// AdminAction は管理操作の記録（lock_admin_actions テーブル）を表す構造体
+type AdminAction struct {
+	LockName     string
+	Key          string
+	ConnectionID int64
+	OwnerUser    string
+	OwnerHost    string
+	Action       string
+	DryRun       bool
+	Reason       string
+	Result       string
+	Error        string
+}
+
+// FindLockOwner は名前付きロックを保持しているセッションを返す
+// IS_USED_LOCK で保持している接続のIDを取得し、information_schema.PROCESSLIST からユーザーとホストを取得する
+// ロックが保持されていない場合は ErrLockNotHeld を返す
+func (db *DB) FindLockOwner(ctx context.Context, name string) (*LockOwner, error) {
+	connID, used, err := db.IsUsedLock(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if !used {
+		return nil, &LockError{Op: "check", LockName: name, Err: ErrLockNotHeld}
+	}
+
+	owner := &LockOwner{
+		LockName: name,
+		Key:      db.LockKey(name),
+		Session:  LockSession{ConnectionID: connID},
+	}
+	query := "SELECT USER, HOST, TIME FROM information_schema.PROCESSLIST WHERE ID = ?"
+	err = db.QueryRowContext(ctx, query, connID).Scan(&owner.Session.User, &owner.Session.Host, &owner.Session.Seconds)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		// 参照する権限がない、または直前に切断された
+	case err != nil:
+		return nil, fmt.Errorf("failed to get lock owner process: %w", err)
+	default:
+		owner.Visible = true
+	}
+	return owner, nil
+}
+
+// ApplicationUser はこのアプリケーションが接続しているMySQLのユーザー名を返す
+// information_schema.PROCESSLIST の USER 列と比較できるように、ホスト部分を除いて返す
+func (db *DB) ApplicationUser(ctx context.Context) (string, error) {
+	var user string
+	if err := db.QueryRowContext(ctx, "SELECT SUBSTRING_INDEX(CURRENT_USER(), '@', 1)").Scan(&user); err != nil {
+		return "", fmt.Errorf("failed to get current user: %w", err)
+	}
+	return user, nil
+}
+
+// KillLockOwner はロックを保持しているセッションの接続（queryOnly の場合は実行中のクエリ）を打ち切る
+// 確認してから KILL するまでの間に所有者が変わっていないことを IS_USED_LOCK で確かめ、
+// 変わっていた場合は ErrLockNotHeld を返す（解放後にプールで再利用された接続を切断しないため）
+func (db *DB) KillLockOwner(ctx context.Context, owner *LockOwner, queryOnly bool) error {
+	connID, used, err := db.IsUsedLock(ctx, owner.LockName)
+	if err != nil {
+		return err
+	}
+	if !used || connID != owner.Session.ConnectionID {
+		return &LockError{Op: "check", LockName: owner.LockName, Err: ErrLockNotHeld}
+	}
+
+	statement := "KILL CONNECTION %d"
+	if queryOnly {
+		statement = "KILL QUERY %d"
+	}
+	// KILL はプレースホルダを使えないため、数値を直接埋め込む
+	if _, err := db.ExecContext(ctx, fmt.Sprintf(statement, owner.Session.ConnectionID)); err != nil {
+		return fmt.Errorf("failed to kill lock owner: %w", err)
+	}
+	return nil
+}
+
+// InsertAdminAction は管理操作を lock_admin_actions テーブルに記録し、記録のIDを返す
+func (db *DB) InsertAdminAction(ctx context.Context, action *AdminAction) (int64, error) {
+	query := `
+		INSERT INTO lock_admin_actions
+			(lock_name, lock_key, connection_id, owner_user, owner_host, action, dry_run, reason, result, error)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	result, err := db.ExecContext(ctx, query,
+		action.LockName, action.Key, action.ConnectionID, action.OwnerUser, action.OwnerHost,
+		action.Action, action.DryRun, action.Reason, action.Result, action.Error)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert admin action: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get admin action ID: %w", err)
+	}
+	return id, nil
+}
+
+// UpdateAdminActionResult は記録済みの管理操作の結果を更新する
+func (db *DB) UpdateAdminActionResult(ctx context.Context, id int64, result string, errMessage string) error {
+	query := "UPDATE lock_admin_actions SET result = ?, error = ? WHERE id = ?"
+	if _, err := db.ExecContext(ctx, query, result, errMessage, id); err != nil {
+		return fmt.Errorf("failed to update admin action: %w", err)
+	}
+	return nil
+}
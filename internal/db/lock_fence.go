@@ -0,0 +1,65 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// fence はトランザクションが書き込みの際に確認するフェンシングトークン
+type fence struct {
+	// key はMySQLのロック名（lock_fences.lock_name）
+	key   string
+	token int64
+}
+
+// issueFenceToken は conn のセッションで、ロックのフェンシングトークンを発行する
+// トークンはロック名ごとに取得のたびに1ずつ増える（最初の取得は1）
+// LAST_INSERT_ID(expr) の値はセッションごとに保持されるため、同時に発行しても他のセッションの値と混ざらない
+func issueFenceToken(ctx context.Context, conn *sql.Conn, key string) (int64, error) {
+	query := `
+		INSERT INTO lock_fences
+		(lock_name, token)
+		VALUES (?, LAST_INSERT_ID(1))
+		ON DUPLICATE KEY UPDATE token = LAST_INSERT_ID(token + 1)`
+	if _, err := conn.ExecContext(ctx, query, key); err != nil {
+		return 0, fmt.Errorf("failed to issue fencing token: %w", err)
+	}
+
+	var token int64
+	if err := conn.QueryRowContext(ctx, "SELECT LAST_INSERT_ID()").Scan(&token); err != nil {
+		return 0, fmt.Errorf("failed to get fencing token: %w", err)
+	}
+	return token, nil
+}
+
+// checkFence はトランザクションが持つロック名のトークンが、最後に発行されたトークンより古くないことを確認する
+// 古い場合は、ロックを失った後に他のセッションがロックを取得しているため ErrStaleFence を返す
+// lock_fences の行を共有ロック（FOR SHARE）で読むため、コミットまでの間に新しいトークンは発行されない
+// ロックを保持していないトランザクション（DB.BeginTx など）や、保持していないロック名の場合は確認しない
+func (tx *Tx) checkFence(lockName string) error {
+	f, ok := tx.fences[lockName]
+	if !ok {
+		return nil
+	}
+
+	var latest int64
+	query := `
+		SELECT token
+		FROM lock_fences
+		WHERE lock_name = ?
+		FOR SHARE`
+	if err := tx.QueryRow(query, f.key).Scan(&latest); err != nil {
+		return fmt.Errorf("failed to check fencing token: %w", err)
+	}
+	if f.token < latest {
+		return fmt.Errorf("%w: lock %q token %d, latest %d", ErrStaleFence, lockName, f.token, latest)
+	}
+	return nil
+}
+
+// FenceToken はトランザクションが持つロック名のフェンシングトークンを返す
+// ロックを保持していない場合は0を返す
+func (tx *Tx) FenceToken(lockName string) int64 {
+	return tx.fences[lockName].token
+}
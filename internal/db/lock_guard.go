@@ -0,0 +1,45 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"sync/atomic"
+)
+
+// lockGuard は名前付きロックを保持したままの接続がプールに戻るのを防ぐ
+// プールに戻った接続を別のリクエストが再利用すると、そのリクエストは他人のロックを
+// 保持した状態になり、同じ名前の GET_LOCK も再入として成功してしまう
+type lockGuard struct {
+	// incidents はロックを保持したままプールに戻ろうとした接続の数
+	incidents atomic.Int64
+}
+
+// returnConn は接続が名前付きロックを保持していないことを確認してからプールに戻す
+// 保持している場合は RELEASE_ALL_LOCKS() ですべて解放してから戻し、
+// 確認・解放できない場合は driver.ErrBadConn で接続を破棄する（どちらもインシデントとして数える）
+// names: 警告に出力する、この接続で取得したロック名
+func (db *DB) returnConn(ctx context.Context, conn *sql.Conn, connID int64, names []string) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), killTimeout)
+	defer cancel()
+
+	// RELEASE_ALL_LOCKS は解放したロックの数（再入した回数を含む）を返すため、確認と解放を1回で行える
+	var released int
+	if err := conn.QueryRowContext(ctx, "SELECT RELEASE_ALL_LOCKS()").Scan(&released); err != nil {
+		db.guard.incidents.Add(1)
+		log.Printf("Warning: failed to check named locks on connection %d (locks: %v), discarding it: %v", connID, names, err)
+		discardConn(conn)
+		return nil
+	}
+	if released > 0 {
+		db.guard.incidents.Add(1)
+		log.Printf("Warning: connection %d was returning to the pool while still holding %d named locks (locks: %v), released them", connID, released, names)
+	}
+	return conn.Close()
+}
+
+// HeldLockIncidents はロックを保持したままプールに戻ろうとした接続の数を返す
+// （ロックを解放してから戻した接続と、破棄した接続の合計）
+func (db *DB) HeldLockIncidents() int64 {
+	return db.guard.incidents.Load()
+}
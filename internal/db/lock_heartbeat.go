@@ -0,0 +1,132 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+)
+
+// lockHeartbeatTimeout はハートビートでロックの保持を確認する際のタイムアウト
+const lockHeartbeatTimeout = 5 * time.Second
+
+// lockHeartbeatMaxFailures はロックの保持を確認できなかった場合に、ロックを失ったとみなす連続回数
+// データベースに接続できない間は、保持しているセッションが生きているかどうかを判断できないため
+const lockHeartbeatMaxFailures = 3
+
+// startHeartbeat はロックを保持しているセッションが、まだロックを保持しているかの定期的な確認を開始する
+// セッションが切断された場合（ネットワーク障害、wait_timeout、KILL など）にロックは暗黙的に解放されるため、
+// それを検知して Lost と WithContext のコンテキストに通知する
+// interval が0以下の場合は確認しない
+func (l *Lock) startHeartbeat(interval time.Duration) {
+	if interval <= 0 {
+		l.stopHeartbeat = func() {}
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	l.stopHeartbeat = cancel
+	go l.heartbeat(ctx, interval, l.names[0])
+}
+
+// heartbeat は interval ごとにロックの保持を確認し、失っていた場合は markLost を呼び出す
+// ロックを保持している接続はトランザクションで使用中の場合があるため、確認には別の接続を使い、
+// IS_USED_LOCK(key) が保持している接続のセッションID（CONNECTION_ID()）と一致するかを調べる
+// lockName: 確認に失敗し続けた場合のエラーに使用するロック名
+func (l *Lock) heartbeat(ctx context.Context, interval time.Duration, lockName string) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	failures := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		lostName, err := l.checkHeld(ctx)
+		if ctx.Err() != nil {
+			// 確認中に解放された
+			return
+		}
+		if err != nil {
+			failures++
+			log.Printf("Warning: failed to check lock on connection %d (%d/%d): %v", l.connID, failures, lockHeartbeatMaxFailures, err)
+			if failures < lockHeartbeatMaxFailures {
+				continue
+			}
+			log.Printf("Lock lost: could not confirm locks are held by connection %d", l.connID)
+			l.markLost(newLockError("hold", lockName, ErrLockLost))
+			return
+		}
+		failures = 0
+
+		if lostName != "" {
+			log.Printf("Lock lost: %s is no longer held by connection %d", lostName, l.connID)
+			l.markLost(newLockError("hold", lostName, ErrLockLost))
+			return
+		}
+	}
+}
+
+// checkHeld はハンドルが保持しているロックのうち、保持していた接続が失ったロック名を返す
+// すべて保持している場合は空文字列を返す
+// 確認中に解放されたロックを失ったと誤検知しないよう、確認の間は l.mu を保持する（解放側も l.mu を保持して解放する）
+func (l *Lock) checkHeld(ctx context.Context) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, lockHeartbeatTimeout)
+	defer cancel()
+	for i, name := range l.names {
+		if l.holds[name] == 0 {
+			continue
+		}
+		var owner sql.NullInt64
+		if err := l.db.QueryRowContext(ctx, "SELECT IS_USED_LOCK(?)", l.keys[i]).Scan(&owner); err != nil {
+			return "", err
+		}
+		if !owner.Valid || owner.Int64 != l.connID {
+			return name, nil
+		}
+	}
+	return "", nil
+}
+
+// Lost はロックを失ったことを検知した時点で閉じられるチャネルを返す
+// 解放した場合は閉じられない
+func (l *Lock) Lost() <-chan struct{} {
+	return l.lostCtx.Done()
+}
+
+// LostErr はロックを失っている場合は ErrLockLost を含む *LockError を返し、そうでない場合は nil を返す
+func (l *Lock) LostErr() error {
+	if l.lostCtx.Err() == nil {
+		return nil
+	}
+	return context.Cause(l.lostCtx)
+}
+
+// WithContext はロックを失った時点でもキャンセルされる、parent から派生したコンテキストを返す
+// ロックを失った場合の context.Cause は ErrLockLost を含む *LockError になる
+// このコンテキストで開始したトランザクションは、ロックを失うとロールバックされ、コミットできなくなる
+func (l *Lock) WithContext(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(parent)
+	stop := context.AfterFunc(l.lostCtx, func() {
+		cancel(context.Cause(l.lostCtx))
+	})
+	return ctx, func() {
+		stop()
+		cancel(context.Canceled)
+	}
+}
+
+// lostError はロックを失っている場合、処理のエラーを ErrLockLost として返す
+// ロックを失ったためにトランザクションが中断された場合に、呼び出し元が原因を判定できるようにする
+func (l *Lock) lostError(err error) error {
+	if l.lostCtx.Err() == nil {
+		return err
+	}
+	return &LockError{Op: "hold", LockName: l.Name(), Err: ErrLockLost, Cause: err}
+}
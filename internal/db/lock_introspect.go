@@ -0,0 +1,219 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ロック一覧の取得元（LockSnapshot.Source）
+const (
+	// LockSourcePerformanceSchema は performance_schema.metadata_locks から取得したことを表す
+	LockSourcePerformanceSchema = "performance_schema"
+	// LockSourceProcess はこのプロセスが保持しているロックのハンドルから取得したことを表す（待機中のセッションは含まない）
+	LockSourceProcess = "process"
+)
+
+// LockSession はロックを保持している、またはロックを待っているセッションを表す構造体
+type LockSession struct {
+	ConnectionID int64
+	User         string
+	Host         string
+	// Seconds は現在の状態（待機中のセッションの場合は GET_LOCK の待機）の経過秒数
+	Seconds int64
+}
+
+// UserLock は名前付きロックの保持者と待機者を表す構造体
+type UserLock struct {
+	// LockName は元のロック名（このプロセスで変換したロック名でない場合は名前空間を取り除いた名前）
+	LockName string
+	// Key はMySQLのロック名
+	Key string
+	// Holder は保持しているセッション（待機者のみの場合は nil）
+	Holder *LockSession
+	// Waiters は GET_LOCK で待機しているセッション
+	Waiters []LockSession
+}
+
+// LockSnapshot はある時点の名前付きロックの一覧を表す構造体
+type LockSnapshot struct {
+	// Source は一覧の取得元（LockSourcePerformanceSchema または LockSourceProcess）
+	Source string
+	// Warning は performance_schema を使用できなかった理由（取得元が LockSourceProcess の場合のみ）
+	Warning string
+	Locks   []UserLock
+}
+
+// heldLocks はこのプロセスで保持中のロックのハンドル
+// performance_schema を使用できない場合のロック一覧に使用する
+type heldLocks struct {
+	mu    sync.Mutex
+	locks map[*Lock]struct{}
+}
+
+// add は保持中のロックのハンドルを登録する
+func (h *heldLocks) add(l *Lock) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.locks[l] = struct{}{}
+}
+
+// remove は解放・破棄したロックのハンドルの登録を解除する
+func (h *heldLocks) remove(l *Lock) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.locks, l)
+}
+
+// snapshot は登録されているハンドルの一覧を返す
+func (h *heldLocks) snapshot() []*Lock {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	locks := make([]*Lock, 0, len(h.locks))
+	for l := range h.locks {
+		locks = append(locks, l)
+	}
+	return locks
+}
+
+// ListUserLocks は名前付きロックの保持者と待機者の一覧を返す
+// performance_schema.metadata_locks（OBJECT_TYPE = 'USER LEVEL LOCK'）と threads を結合して取得し、
+// performance_schema が無効、メタデータロックの計測が無効、または参照する権限がない場合は、
+// このプロセスが保持しているロックの一覧を返す（待機者は含まない）
+// 名前空間が設定されている場合は、名前空間に属するロックのみを返す
+func (db *DB) ListUserLocks(ctx context.Context) (*LockSnapshot, error) {
+	reason, err := db.performanceSchemaUnavailable(ctx)
+	if err == nil && reason == "" {
+		locks, err := db.queryMetadataLocks(ctx)
+		if err == nil {
+			return &LockSnapshot{Source: LockSourcePerformanceSchema, Locks: locks}, nil
+		}
+		reason = err.Error()
+	} else if err != nil {
+		reason = err.Error()
+	}
+
+	locks, err := db.processLocks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &LockSnapshot{Source: LockSourceProcess, Warning: reason, Locks: locks}, nil
+}
+
+// performanceSchemaUnavailable は performance_schema で名前付きロックを参照できない理由を返す
+// 参照できる場合は空文字列を返す
+func (db *DB) performanceSchemaUnavailable(ctx context.Context) (string, error) {
+	var enabled bool
+	if err := db.QueryRowContext(ctx, "SELECT @@performance_schema").Scan(&enabled); err != nil {
+		return "", fmt.Errorf("failed to check performance_schema: %w", err)
+	}
+	if !enabled {
+		return "performance_schema is disabled", nil
+	}
+
+	var instrumented string
+	query := `
+		SELECT ENABLED
+		FROM performance_schema.setup_instruments
+		WHERE NAME = 'wait/lock/metadata/sql/mdl'`
+	if err := db.QueryRowContext(ctx, query).Scan(&instrumented); err != nil {
+		return "", fmt.Errorf("failed to check metadata lock instrumentation: %w", err)
+	}
+	if instrumented != "YES" {
+		return "metadata lock instrumentation (wait/lock/metadata/sql/mdl) is disabled", nil
+	}
+	return "", nil
+}
+
+// queryMetadataLocks は performance_schema から名前付きロックの保持者と待機者を取得する
+func (db *DB) queryMetadataLocks(ctx context.Context) ([]UserLock, error) {
+	query := `
+		SELECT ml.OBJECT_NAME, ml.LOCK_STATUS, t.PROCESSLIST_ID,
+			COALESCE(t.PROCESSLIST_USER, ''), COALESCE(t.PROCESSLIST_HOST, ''), COALESCE(t.PROCESSLIST_TIME, 0)
+		FROM performance_schema.metadata_locks ml
+		JOIN performance_schema.threads t ON t.THREAD_ID = ml.OWNER_THREAD_ID
+		WHERE ml.OBJECT_TYPE = 'USER LEVEL LOCK'
+		ORDER BY ml.OBJECT_NAME, t.PROCESSLIST_TIME DESC`
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query metadata locks: %w", err)
+	}
+	defer rows.Close()
+
+	var locks []UserLock
+	byKey := make(map[string]int)
+	for rows.Next() {
+		var key, status string
+		var session LockSession
+		var connID sql.NullInt64
+		if err := rows.Scan(&key, &status, &connID, &session.User, &session.Host, &session.Seconds); err != nil {
+			return nil, fmt.Errorf("failed to scan metadata lock: %w", err)
+		}
+		if !strings.HasPrefix(key, db.namer.namespace) {
+			continue
+		}
+		session.ConnectionID = connID.Int64
+
+		i, ok := byKey[key]
+		if !ok {
+			i = len(locks)
+			byKey[key] = i
+			locks = append(locks, UserLock{LockName: db.OriginalLockName(key), Key: key})
+		}
+		// GRANTED は保持者、PENDING は GET_LOCK で待機しているセッション
+		if status == "GRANTED" {
+			holder := session
+			locks[i].Holder = &holder
+		} else {
+			locks[i].Waiters = append(locks[i].Waiters, session)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over metadata locks: %w", err)
+	}
+	return locks, nil
+}
+
+// processLocks はこのプロセスが保持しているロックの一覧を返す
+// 保持している接続のホストと経過秒数は、information_schema.PROCESSLIST から取得できた場合のみ設定する
+func (db *DB) processLocks(ctx context.Context) ([]UserLock, error) {
+	sessions := make(map[int64]LockSession)
+	rows, err := db.QueryContext(ctx, "SELECT ID, USER, HOST, TIME FROM information_schema.PROCESSLIST")
+	if err == nil {
+		defer rows.Close()
+		for rows.Next() {
+			var session LockSession
+			if err := rows.Scan(&session.ConnectionID, &session.User, &session.Host, &session.Seconds); err != nil {
+				return nil, fmt.Errorf("failed to scan process: %w", err)
+			}
+			sessions[session.ConnectionID] = session
+		}
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("error iterating over processes: %w", err)
+		}
+	}
+
+	var locks []UserLock
+	for _, l := range db.held.snapshot() {
+		session, ok := sessions[l.connID]
+		if !ok {
+			session = LockSession{ConnectionID: l.connID}
+		}
+
+		l.mu.Lock()
+		for i, name := range l.names {
+			if l.holds[name] == 0 {
+				continue
+			}
+			holder := session
+			locks = append(locks, UserLock{LockName: name, Key: l.keys[i], Holder: &holder})
+		}
+		l.mu.Unlock()
+	}
+	sort.Slice(locks, func(i, j int) bool { return locks[i].Key < locks[j].Key })
+	return locks, nil
+}
@@ -0,0 +1,104 @@
+package db
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// maxLockNameLength はMySQLの名前付きロック名の最大文字数
+const maxLockNameLength = 64
+
+// lockNameHashLength はロック名が長すぎる場合に付与するハッシュの文字数
+const lockNameHashLength = 32
+
+// maxHashedNames は元のロック名を記憶しておくハッシュ化済みロック名の最大数
+const maxHashedNames = 10000
+
+// derivedNameSeparator は元の名前から派生したロック名の区切り文字
+// 読み取り・書き込みロックのゲートとストライプ（name#w, name#r0）、セマフォのスロット（name#0）と順番待ち（name#s）、
+// 順番待ちの番号ごとのロック（公平モードでは name#q1、セマフォでは name#s#q1）、リーダー選出のロック（name#leader）、
+// 定期実行ジョブのロック（name#job）は、元の名前に # と接尾辞を付けて作る
+// 元の名前が # を含むと派生したロック名が他のロック名と衝突するため、派生したロック名を作る入口では
+// CheckBaseName で # を含む名前を拒否する（HTTPの検証のパターンは設定で変更できるため、それに頼らない）
+const derivedNameSeparator = "#"
+
+// errReservedSeparator は元の名前が派生したロック名の区切り文字を含むことを表す
+var errReservedSeparator = errors.New("name must not contain " + derivedNameSeparator)
+
+// DerivedLockName は元の名前に区切り文字と接尾辞を付けた、派生したロック名を返す
+func DerivedLockName(name string, suffix string) string {
+	return name + derivedNameSeparator + suffix
+}
+
+// CheckBaseName は派生したロック名の元にする名前が、区切り文字を含まないことを確認する
+// 含む場合は ErrLockNameInvalid を含む *LockError を返す
+func CheckBaseName(name string) error {
+	if strings.Contains(name, derivedNameSeparator) {
+		return &LockError{Op: "acquire", LockName: name, Err: ErrLockNameInvalid, Cause: errReservedSeparator}
+	}
+	return nil
+}
+
+// lockNamer はアプリケーションのロック名をMySQLのロック名に変換する
+// 名前空間を前置し、64文字を超える場合は読みやすい先頭部分を残してハッシュ化する
+type lockNamer struct {
+	namespace string
+
+	mu sync.Mutex
+	// originals はハッシュ化したMySQLのロック名から元のロック名への対応
+	originals map[string]string
+}
+
+// newLockNamer は新しいlockNamerインスタンスを作成する
+func newLockNamer(namespace string) *lockNamer {
+	return &lockNamer{
+		namespace: namespace,
+		originals: make(map[string]string),
+	}
+}
+
+// key はロック名に対応するMySQLのロック名を返す
+// 同じロック名からは常に同じMySQLのロック名が得られる
+func (n *lockNamer) key(name string) string {
+	full := n.namespace + name
+	if utf8.RuneCountInString(full) <= maxLockNameLength {
+		return full
+	}
+
+	// 先頭部分（区切りの # とハッシュを除いた長さ）を残し、全体のハッシュを付与する
+	sum := sha256.Sum256([]byte(full))
+	prefix := []rune(full)[:maxLockNameLength-lockNameHashLength-1]
+	key := string(prefix) + "#" + hex.EncodeToString(sum[:])[:lockNameHashLength]
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if _, ok := n.originals[key]; !ok && len(n.originals) >= maxHashedNames {
+		// 上限に達した場合は任意のひとつを忘れる（表示用のため、失っても動作には影響しない）
+		for k := range n.originals {
+			delete(n.originals, k)
+			break
+		}
+	}
+	n.originals[key] = name
+
+	return key
+}
+
+// original はMySQLのロック名から元のロック名を返す
+// このプロセスで変換したロック名でない場合は、名前空間を取り除いた名前（取り除けない場合はそのまま）を返す
+func (n *lockNamer) original(key string) string {
+	n.mu.Lock()
+	name, ok := n.originals[key]
+	n.mu.Unlock()
+	if ok {
+		return name
+	}
+	if len(key) >= len(n.namespace) && key[:len(n.namespace)] == n.namespace {
+		return key[len(n.namespace):]
+	}
+	return key
+}
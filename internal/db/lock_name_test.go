@@ -0,0 +1,135 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestLockNamerKey(t *testing.T) {
+	const namespace = "app:"
+	long := strings.Repeat("x", maxLockNameLength)
+	tests := []struct {
+		name string
+		// wantHashed はハッシュ化されるか（されない場合は名前空間を前置しただけの名前になる）
+		wantHashed bool
+	}{
+		{"product123", false},
+		// 名前空間を含めてちょうど64文字
+		{long[:maxLockNameLength-len(namespace)], false},
+		{long[:maxLockNameLength-len(namespace)+1], true},
+		{"tenant_0123456789/warehouse_tokyo_east/product_" + strings.Repeat("y", 32), true},
+		// 64文字はバイト数ではなく文字数で数える
+		{strings.Repeat("あ", maxLockNameLength-len(namespace)), false},
+		{strings.Repeat("あ", maxLockNameLength), true},
+	}
+	for _, tt := range tests {
+		n := newLockNamer(namespace)
+		key := n.key(tt.name)
+
+		if !tt.wantHashed {
+			if key != namespace+tt.name {
+				t.Errorf("key(%q) = %q, want %q", tt.name, key, namespace+tt.name)
+			}
+			continue
+		}
+
+		if got := utf8.RuneCountInString(key); got != maxLockNameLength {
+			t.Errorf("key(%q) has %d characters, want %d", tt.name, got, maxLockNameLength)
+		}
+		// 読みやすい先頭部分と、区切りの # とハッシュ
+		prefix := string([]rune(namespace + tt.name)[:maxLockNameLength-lockNameHashLength-1])
+		if !strings.HasPrefix(key, prefix+"#") {
+			t.Errorf("key(%q) = %q, want prefix %q", tt.name, key, prefix+"#")
+		}
+		if key != n.key(tt.name) {
+			t.Errorf("key(%q) is not stable", tt.name)
+		}
+		if got := n.original(key); got != tt.name {
+			t.Errorf("original(%q) = %q, want %q", key, got, tt.name)
+		}
+	}
+}
+
+func TestLockNamerKeyDistinguishesSharedPrefix(t *testing.T) {
+	// 先頭部分が同じでも、全体のハッシュが異なるため別のMySQLのロック名になる
+	n := newLockNamer("")
+	base := strings.Repeat("x", maxLockNameLength)
+	a, b := n.key(base+"a"), n.key(base+"b")
+	if a == b {
+		t.Fatalf("key(%q) and key(%q) collide: %q", base+"a", base+"b", a)
+	}
+	// 名前空間が異なれば同じ名前でも別のMySQLのロック名になる
+	if newLockNamer("x:").key(base) == newLockNamer("y:").key(base) {
+		t.Error("keys in different namespaces collide")
+	}
+}
+
+func TestLockNamerOriginal(t *testing.T) {
+	n := newLockNamer("app:")
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"app:product123", "product123"},
+		// 名前空間が一致しない名前（他のアプリケーションのロックなど）はそのまま返す
+		{"other:product123", "other:product123"},
+		{"ap", "ap"},
+	}
+	for _, tt := range tests {
+		if got := n.original(tt.key); got != tt.want {
+			t.Errorf("original(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestLockNamerBoundsRememberedNames(t *testing.T) {
+	n := newLockNamer("")
+	base := strings.Repeat("x", maxLockNameLength)
+	for i := range maxHashedNames + 10 {
+		n.key(fmt.Sprintf("%s%d", base, i))
+	}
+	if got := len(n.originals); got != maxHashedNames {
+		t.Errorf("remembered %d names, want %d", got, maxHashedNames)
+	}
+}
+
+func TestCheckBaseName(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{"product123", false},
+		{"tenant/warehouse/product", false},
+		{"product#w", true},
+		{"#", true},
+	}
+	for _, tt := range tests {
+		err := CheckBaseName(tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("CheckBaseName(%q) = %v, want error: %t", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err != nil && !errors.Is(err, ErrLockNameInvalid) {
+			t.Errorf("CheckBaseName(%q) = %v, want ErrLockNameInvalid", tt.name, err)
+		}
+	}
+}
+
+func TestDerivedLockNamesDoNotCollide(t *testing.T) {
+	// 元の名前が # を含まなければ、異なる種類の派生したロック名が一致することはない
+	names := map[string]string{
+		rwGateName("orders"):                                 "rw gate",
+		rwStripeName("orders", 0):                            "rw stripe",
+		semaphoreSlotName("orders", 0):                       "semaphore slot",
+		semaphoreQueueName("orders"):                         "semaphore queue",
+		queueTicketLockName(semaphoreQueueName("orders"), 1): "queue ticket",
+		ElectionLockName("orders"):                           "election",
+		"orders":                                             "base",
+	}
+	if len(names) != 7 {
+		t.Fatalf("derived lock names collide: %v", names)
+	}
+}
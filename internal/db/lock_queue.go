@@ -0,0 +1,257 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+)
+
+// queuePollInterval は順番待ちの先頭になったかを確認する間隔
+const queuePollInterval = 100 * time.Millisecond
+
+// 順番待ち
+// GET_LOCK は待機しているセッションの順序を保証しないため、lock_queue テーブルで順番待ちの番号を発行し、
+// 順番待ちの先頭になったセッションだけが取得を試みる（順番待ちのセッションの間では、並んだ順に取得する）
+// 公平モードの名前付きロック（AcquireFair）とセマフォの許可（Semaphore.Acquire）で使う
+// 順番待ちの接続は番号ごとのロック（queueTicketLockName）を保持し、先頭の番号のロックが保持されていない場合は
+// 接続が切断されたものとして、その順番待ちを取り除いて次に進む
+
+// QueueStats は順番待ち（公平モード）でロックを取得したときの統計を表す構造体
+type QueueStats struct {
+	// Ticket は順番待ちの番号（lock_queue テーブルのID。小さいほど先に並んだ）
+	Ticket int64
+	// Position は順番待ちに並んだ時点で、前に並んでいた順番待ちの数
+	Position int
+	// Abandoned は前に並んでいた順番待ちのうち、接続が切断されていたため取り除いた数
+	Abandoned int
+	// Waited は順番待ちに並んでからロックを取得するまでの時間
+	Waited time.Duration
+}
+
+// AcquireFair は順番待ち（公平モード）で名前付きロックを取得し、ロックを保持している接続のハンドルを返す
+// 順番待ちの先頭になってから GET_LOCK を実行する（公平モードで待っているセッションの間では、並んだ順に取得する）
+// ロック待ちのタイムアウトはコンテキストの期限から求め、順番待ちと GET_LOCK の待機にまとめて適用する
+// 公平モードでない AcquireLock との間では順序を保証しない
+func (db *DB) AcquireFair(ctx context.Context, lockName string) (*Lock, *QueueStats, error) {
+	if err := CheckBaseName(lockName); err != nil {
+		return nil, nil, err
+	}
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return nil, nil, newLockError("acquire", lockName, ctx.Err())
+	}
+
+	conn, connID, err := db.lockConn(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 順番待ちの登録と取り除きは、取得の期限やキャンセルに関係なく行う
+	queueCtx := context.WithoutCancel(ctx)
+	startedAt := time.Now()
+	waiter, err := db.enqueueLockWaiter(queueCtx, conn, lockName, connID)
+	if err != nil {
+		db.returnConn(queueCtx, conn, connID, nil)
+		return nil, nil, newLockError("acquire", lockName, err)
+	}
+
+	stats := &QueueStats{Ticket: waiter.ticket}
+	stats.Position, err = waiter.position(queueCtx, conn)
+	if err != nil {
+		db.leaveQueue(queueCtx, conn, connID, waiter)
+		return nil, nil, newLockError("acquire", lockName, err)
+	}
+
+	stats.Abandoned, err = db.waitQueueHead(ctx, conn, waiter)
+	if err != nil {
+		db.leaveQueue(queueCtx, conn, connID, waiter)
+		return nil, stats, newLockError("acquire", lockName, err)
+	}
+
+	key := db.LockKey(lockName)
+	acquired, err := db.waitNamedLock(ctx, conn, connID, key, lockWaitSeconds(ctx))
+	if err != nil {
+		// 接続は waitNamedLock で破棄済み（番号のロックも解放済み）のため、順番待ちは別の接続から取り除く
+		db.removeLockWaiter(queueCtx, waiter)
+		return nil, stats, newLockError("acquire", lockName, err)
+	}
+	if !acquired {
+		db.leaveQueue(queueCtx, conn, connID, waiter)
+		return nil, stats, newLockError("acquire", lockName, ErrLockTimeout)
+	}
+
+	// ロックを取得したら順番待ちから外れ、次の順番待ちが GET_LOCK で待てるようにする
+	if err := waiter.leave(queueCtx, conn); err != nil {
+		db.releasePartialLocks(conn, connID, []string{lockName, waiter.name}, []string{key, waiter.key})
+		return nil, stats, newLockError("acquire", lockName, err)
+	}
+	stats.Waited = time.Since(startedAt)
+
+	lock, err := db.newAcquiredLock(ctx, conn, connID, lockName, key)
+	if err != nil {
+		return nil, stats, err
+	}
+	return lock, stats, nil
+}
+
+// lockWaiter は順番待ちの番号と、番号ごとのロック
+type lockWaiter struct {
+	// queue は順番待ちの名前、queueKey は lock_queue テーブルに記録する、対応するMySQLのロック名
+	queue    string
+	queueKey string
+	ticket   int64
+	// name は番号ごとのロック名、key は対応するMySQLのロック名
+	name string
+	key  string
+}
+
+// queueTicketLockName は順番待ちの番号ごとのロック名を返す
+// 順番待ちの接続は、順番待ちから外れるまで番号のロックを保持する
+// 接続が切断されるとロックも解放されるため、IS_USED_LOCK で順番待ちの接続が生きているかを確認できる
+func queueTicketLockName(queue string, ticket int64) string {
+	return DerivedLockName(queue, "q"+strconv.FormatInt(ticket, 10))
+}
+
+// queueLength は順番待ちに並んでいる数を返す
+func (db *DB) queueLength(ctx context.Context, conn *sql.Conn, queue string) (int, error) {
+	var n int
+	query := "SELECT COUNT(*) FROM lock_queue WHERE lock_name = ?"
+	if err := conn.QueryRowContext(ctx, query, db.LockKey(queue)).Scan(&n); err != nil {
+		return 0, fmt.Errorf("failed to get lock queue length: %w", err)
+	}
+	return n, nil
+}
+
+// enqueueLockWaiter は順番待ちの番号を発行し、番号のロックを取得する
+// 番号の発行と番号のロックの取得をひとつのトランザクションで行い、コミットするまで他のセッションから番号が見えないようにする
+// （番号のロックを取得する前の順番待ちが、切断されたものとして取り除かれないようにするため）
+func (db *DB) enqueueLockWaiter(ctx context.Context, conn *sql.Conn, queue string, connID int64) (*lockWaiter, error) {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin lock queue transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	queueKey := db.LockKey(queue)
+	result, err := tx.ExecContext(ctx, "INSERT INTO lock_queue (lock_name, connection_id) VALUES (?, ?)", queueKey, connID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enqueue lock waiter: %w", err)
+	}
+	ticket, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lock queue ticket: %w", err)
+	}
+
+	// 番号は一意のため、番号のロックは待機なしで取得できる
+	waiter := &lockWaiter{queue: queue, queueKey: queueKey, ticket: ticket, name: queueTicketLockName(queue, ticket)}
+	waiter.key = db.LockKey(waiter.name)
+	var acquired sql.NullInt64
+	if err := tx.QueryRowContext(ctx, "SELECT GET_LOCK(?, 0)", waiter.key).Scan(&acquired); err != nil {
+		return nil, fmt.Errorf("failed to lock queue ticket %d: %w", ticket, err)
+	}
+	if acquired.Int64 != 1 {
+		return nil, fmt.Errorf("failed to lock queue ticket %d: already locked", ticket)
+	}
+
+	if err := tx.Commit(); err != nil {
+		// 名前付きロックはトランザクションに含まれないため、番号のロックは明示的に解放する
+		conn.ExecContext(ctx, "DO RELEASE_LOCK(?)", waiter.key)
+		return nil, fmt.Errorf("failed to commit lock queue ticket: %w", err)
+	}
+	return waiter, nil
+}
+
+// position は順番待ちの前に並んでいる数を返す
+func (w *lockWaiter) position(ctx context.Context, conn *sql.Conn) (int, error) {
+	var n int
+	query := "SELECT COUNT(*) FROM lock_queue WHERE lock_name = ? AND id < ?"
+	if err := conn.QueryRowContext(ctx, query, w.queueKey, w.ticket).Scan(&n); err != nil {
+		return 0, fmt.Errorf("failed to get lock queue position: %w", err)
+	}
+	return n, nil
+}
+
+// waitQueueHead は順番待ちの先頭になるまで待ち、取り除いた順番待ちの数を返す
+// 先頭の番号のロックがどのセッションにも保持されていない場合は、接続が切断されたものとして取り除く
+func (db *DB) waitQueueHead(ctx context.Context, conn *sql.Conn, w *lockWaiter) (int, error) {
+	queryCtx := context.WithoutCancel(ctx)
+	abandoned := 0
+	for {
+		var head, headConnID int64
+		query := "SELECT id, connection_id FROM lock_queue WHERE lock_name = ? ORDER BY id LIMIT 1"
+		if err := conn.QueryRowContext(queryCtx, query, w.queueKey).Scan(&head, &headConnID); err != nil {
+			return abandoned, fmt.Errorf("failed to get lock queue head: %w", err)
+		}
+		if head == w.ticket {
+			return abandoned, nil
+		}
+
+		var holder sql.NullInt64
+		headKey := db.LockKey(queueTicketLockName(w.queue, head))
+		if err := conn.QueryRowContext(queryCtx, "SELECT IS_USED_LOCK(?)", headKey).Scan(&holder); err != nil {
+			return abandoned, fmt.Errorf("failed to check lock queue head: %w", err)
+		}
+		if !holder.Valid {
+			// 順番待ちのまま接続が切断された（プロセスの停止など）
+			if err := deleteLockWaiter(queryCtx, conn, head); err != nil {
+				return abandoned, err
+			}
+			abandoned++
+			log.Printf("Removed abandoned lock queue ticket %d (connection ID: %d)", head, headConnID)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return abandoned, ctx.Err()
+		case <-time.After(queuePollInterval):
+		}
+	}
+}
+
+// leave は順番待ちを取り除いてから、番号のロックを解放する
+// 取り除く前に解放すると、生きている順番待ちが切断されたものとして取り除かれることがあるため、この順で行う
+func (w *lockWaiter) leave(ctx context.Context, conn *sql.Conn) error {
+	if err := deleteLockWaiter(ctx, conn, w.ticket); err != nil {
+		return err
+	}
+	var released sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT RELEASE_LOCK(?)", w.key).Scan(&released); err != nil {
+		return fmt.Errorf("failed to release lock queue ticket %d: %w", w.ticket, err)
+	}
+	if released.Int64 != 1 {
+		return fmt.Errorf("failed to release lock queue ticket %d: not held", w.ticket)
+	}
+	return nil
+}
+
+// leaveQueue はロックを取得せずに順番待ちから外れ、接続をプールに戻す
+// 外れられなかった場合は、接続が生きている順番待ちが残って後続が進めなくならないよう、接続を破棄する
+// （破棄した接続の順番待ちは、番号のロックが解放されるため、後続が先頭になった時点で取り除く）
+func (db *DB) leaveQueue(ctx context.Context, conn *sql.Conn, connID int64, w *lockWaiter) {
+	if err := w.leave(ctx, conn); err != nil {
+		log.Printf("Warning: %v (connection ID: %d)", err, connID)
+		discardConn(conn)
+		return
+	}
+	db.returnConn(ctx, conn, connID, nil)
+}
+
+// removeLockWaiter は接続を破棄した順番待ちを、別の接続から取り除く
+// 破棄した接続の番号のロックは解放されているため、取り除けなかった場合も後続が先頭になった時点で取り除かれる
+func (db *DB) removeLockWaiter(ctx context.Context, w *lockWaiter) {
+	if _, err := db.ExecContext(ctx, "DELETE FROM lock_queue WHERE id = ?", w.ticket); err != nil {
+		log.Printf("Warning: failed to remove lock queue ticket %d: %v", w.ticket, err)
+	}
+}
+
+// deleteLockWaiter は順番待ちを取り除く
+func deleteLockWaiter(ctx context.Context, conn *sql.Conn, ticket int64) error {
+	if _, err := conn.ExecContext(ctx, "DELETE FROM lock_queue WHERE id = ?", ticket); err != nil {
+		return fmt.Errorf("failed to remove lock queue ticket %d: %w", ticket, err)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package db
+
+import (
+	"context"
+	"math/rand/v2"
+	"strconv"
+)
+
+// 読み取り・書き込みロック
+// MySQLの名前付きロックは排他ロックのみのため、ひとつのロック名を次の名前付きロックに分けて実現する
+//
+//   - name#w: 書き込みの意図を表すゲート。書き込み側は保持したまま、読み取り側はストライプを取得するまで保持する
+//   - name#r0 〜 name#r(N-1): 読み取りのストライプ。読み取り側はいずれかひとつを、書き込み側はすべてを保持する
+//
+// ゲートを通過できるのは一度にひとつのセッションだけで、ストライプを待っている間もゲートを保持するため、
+// 書き込み側が待ち始めた後に来た読み取り側はゲートで待たされる
+// （書き込み側の優先。読み取りが途切れなくても書き込みが飢餓状態にならない）
+// 書き込み側は元のロック名の名前付きロックも保持するため、同じ名前を WithNamedLock で取得する処理とも同時に実行しない
+// 取得の順序は常に元のロック名、ゲート、ストライプの順で、ストライプだけを保持している読み取り側は何も待たないため、デッドロックしない
+
+// rwGateName は書き込みの意図を表すゲートのロック名を返す
+func rwGateName(lockName string) string {
+	return DerivedLockName(lockName, "w")
+}
+
+// rwStripeName は読み取りのストライプのロック名を返す
+func rwStripeName(lockName string, i int) string {
+	return DerivedLockName(lockName, "r"+strconv.Itoa(i))
+}
+
+// AcquireRead は読み取りロック（共有ロック）を取得し、ロックを保持している接続のハンドルを返す
+// ゲートを取得して書き込み側がいないことを確かめてから、空いているストライプを待機なしで取得する
+// すべてのストライプが使用中の場合は、ゲートを保持したままストライプのひとつを待つ
+// （ゲートを解放してから待つと、後から来た書き込み側より先にストライプを取得してしまう）
+// ストライプを取得した後にゲートを解放するため、返すハンドルが保持しているのはストライプだけになる
+// ロック待ちのタイムアウトはコンテキストの期限から求め、ゲートとストライプの取得にまとめて適用する
+func (db *DB) AcquireRead(ctx context.Context, lockName string) (*Lock, error) {
+	if err := CheckBaseName(lockName); err != nil {
+		return nil, err
+	}
+	gate := rwGateName(lockName)
+	lock, err := db.AcquireLock(ctx, gate)
+	if err != nil {
+		return nil, err
+	}
+	// 解放は取得の期限やキャンセルに関係なく行う
+	releaseCtx := context.WithoutCancel(ctx)
+
+	if err := lock.acquireStripe(ctx, lockName); err != nil {
+		lock.Release(releaseCtx)
+		return nil, err
+	}
+	// 解放したゲートではなく、保持しているストライプがハンドルの代表になる（Name, FenceToken など）
+	if err := lock.ReleaseOne(releaseCtx, gate); err != nil {
+		lock.Release(releaseCtx)
+		return nil, err
+	}
+	return lock, nil
+}
+
+// acquireStripe はゲートを保持している接続で、読み取りのストライプのひとつを取得する
+// 読み取り側が同じストライプに集中しないよう、ランダムな位置から空いているストライプを待機なしで探し、
+// すべて使用中の場合はその位置のストライプを待つ
+func (l *Lock) acquireStripe(ctx context.Context, lockName string) error {
+	offset := rand.IntN(l.db.rwStripes)
+	for i := range l.db.rwStripes {
+		acquired, err := l.TryAcquire(ctx, rwStripeName(lockName, (offset+i)%l.db.rwStripes))
+		if err != nil {
+			return err
+		}
+		if acquired {
+			return nil
+		}
+	}
+	return l.Acquire(ctx, rwStripeName(lockName, offset))
+}
+
+// AcquireWrite は書き込みロック（排他ロック）を取得し、ロックを保持している接続のハンドルを返す
+// 元のロック名の名前付きロック、ゲートの順に取得してから、すべてのストライプを順に取得する（保持中の読み取りロックが解放されるのを待つ）
+// ハンドルの代表は元のロック名のため、書き込みは元のロック名のフェンシングトークンで確認する
+// ロック待ちのタイムアウトはコンテキストの期限から求め、すべての取得にまとめて適用する
+func (db *DB) AcquireWrite(ctx context.Context, lockName string) (*Lock, error) {
+	if err := CheckBaseName(lockName); err != nil {
+		return nil, err
+	}
+	lock, err := db.AcquireLock(ctx, lockName)
+	if err != nil {
+		return nil, err
+	}
+	if err := lock.Acquire(ctx, rwGateName(lockName)); err != nil {
+		lock.Release(context.WithoutCancel(ctx))
+		return nil, err
+	}
+	for i := range db.rwStripes {
+		if err := lock.Acquire(ctx, rwStripeName(lockName, i)); err != nil {
+			lock.Release(context.WithoutCancel(ctx))
+			return nil, err
+		}
+	}
+	return lock, nil
+}
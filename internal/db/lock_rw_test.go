@@ -0,0 +1,116 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitLocked はロック名が他のセッションに保持されるまで待つ
+func waitLocked(t *testing.T, db *DB, lockName string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		status, err := db.GetLockStatus(context.Background(), lockName)
+		if err != nil {
+			t.Fatalf("failed to get lock status: %v", err)
+		}
+		if status.IsLocked() {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("lock %s is not held", lockName)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestAcquireReadHandleNamesStripe(t *testing.T) {
+	db := openTestDB(t)
+	lockName := testLockName(t)
+
+	reader, err := db.AcquireRead(context.Background(), lockName)
+	if err != nil {
+		t.Fatalf("failed to acquire read lock: %v", err)
+	}
+	defer reader.Release(context.Background())
+
+	// 解放したゲートではなく、保持しているストライプがハンドルの代表になる
+	if name := reader.Name(); !strings.HasPrefix(name, lockName+"#r") {
+		t.Errorf("Name() = %s, want a stripe of %s", name, lockName)
+	}
+	if reader.HoldCount(rwGateName(lockName)) != 0 {
+		t.Errorf("reader still holds the gate %s", rwGateName(lockName))
+	}
+	if token := reader.FenceToken(); token == 0 || token != reader.FenceTokenFor(reader.Name()) {
+		t.Errorf("FenceToken() = %d, want the token of %s (%d)", token, reader.Name(), reader.FenceTokenFor(reader.Name()))
+	}
+}
+
+func TestWaitingWriterBlocksNewReaders(t *testing.T) {
+	db := openTestDB(t)
+	db.rwStripes = 2
+	lockName := testLockName(t)
+
+	reader, err := db.AcquireRead(context.Background(), lockName)
+	if err != nil {
+		t.Fatalf("failed to acquire read lock: %v", err)
+	}
+
+	// 書き込み側は保持中の読み取りが終わるのを、ゲートを保持したまま待つ
+	type result struct {
+		lock *Lock
+		err  error
+	}
+	writerDone := make(chan result, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		lock, err := db.AcquireWrite(ctx, lockName)
+		writerDone <- result{lock, err}
+	}()
+	waitLocked(t, db, rwGateName(lockName))
+
+	// 空いているストライプがあっても、書き込み側が待ち始めた後の読み取りはゲートで待たされる
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	late, err := db.AcquireRead(ctx, lockName)
+	cancel()
+	if err == nil {
+		late.Release(context.Background())
+		t.Fatal("new reader acquired the read lock while a writer was waiting")
+	}
+	if !errors.Is(err, ErrLockTimeout) {
+		t.Errorf("new reader error = %v, want ErrLockTimeout", err)
+	}
+
+	select {
+	case res := <-writerDone:
+		t.Fatalf("writer finished while a reader held the lock: %v", res.err)
+	default:
+	}
+
+	if err := reader.Release(context.Background()); err != nil {
+		t.Fatalf("failed to release read lock: %v", err)
+	}
+	res := <-writerDone
+	if res.err != nil {
+		t.Fatalf("failed to acquire write lock: %v", res.err)
+	}
+	if res.lock.Name() != lockName {
+		t.Errorf("writer Name() = %s, want %s", res.lock.Name(), lockName)
+	}
+	if err := res.lock.Release(context.Background()); err != nil {
+		t.Fatalf("failed to release write lock: %v", err)
+	}
+
+	// 書き込み側が解放した後は、読み取りを取得できる
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	reader, err = db.AcquireRead(ctx, lockName)
+	if err != nil {
+		t.Fatalf("failed to acquire read lock after the writer: %v", err)
+	}
+	reader.Release(context.Background())
+}
@@ -0,0 +1,192 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math/rand/v2"
+	"strconv"
+	"time"
+)
+
+// Semaphore は名前付きロックで実現するカウンティングセマフォ
+// permits 個のスロット（name#0 〜 name#(permits-1)）の名前付きロックのいずれかひとつを保持したセッションが許可を得る
+// 同時に許可を得られるセッションは、サーバーのプロセス数に関係なく permits 個まで
+// 許可を待つセッションは順番待ち（lock_queue テーブル）に並び、並んだ順に許可を得る
+type Semaphore struct {
+	db      *DB
+	name    string
+	permits int
+}
+
+// Semaphore は permits 個の許可を持つセマフォを返す
+// 同じ名前には常に同じ permits を指定する（異なる permits を混在させると、許可の上限が守られない）
+// スロットのロック名は名前から派生させるため、名前に # は使えない
+func (db *DB) Semaphore(name string, permits int) (*Semaphore, error) {
+	if err := CheckBaseName(name); err != nil {
+		return nil, err
+	}
+	if permits <= 0 {
+		return nil, errors.New("semaphore permits must be greater than 0")
+	}
+	return &Semaphore{
+		db:      db,
+		name:    name,
+		permits: permits,
+	}, nil
+}
+
+// semaphoreSlotName はセマフォのスロットのロック名を返す
+func semaphoreSlotName(name string, slot int) string {
+	return DerivedLockName(name, strconv.Itoa(slot))
+}
+
+// semaphoreQueueName はセマフォの許可を待つ順番待ちの名前を返す
+func semaphoreQueueName(name string) string {
+	return DerivedLockName(name, "s")
+}
+
+// Name はセマフォの名前を返す
+func (s *Semaphore) Name() string {
+	return s.name
+}
+
+// Permits は同時に得られる許可の数を返す
+func (s *Semaphore) Permits() int {
+	return s.permits
+}
+
+// Acquire は許可を得るまで待ち、スロットのロックを保持している接続のハンドルを返す
+// 保持しているスロットのロック名は Lock.Name で確認できる
+// 順番待ちがなく空いているスロットがあればすぐに許可を得る。そうでない場合は順番待ちに並び、
+// 先頭になってから queuePollInterval ごとにすべてのスロットを確認する
+// （どのサーバーの保持者がどのスロットを解放しても、その間隔以内に先頭の順番待ちが許可を得る）
+// ロック待ちのタイムアウトはコンテキストの期限から求め、順番待ちとスロットの確認にまとめて適用する（期限がない場合は無期限に待つ）
+func (s *Semaphore) Acquire(ctx context.Context) (*Lock, error) {
+	lock, acquired, err := s.acquire(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	if !acquired {
+		return nil, newLockError("acquire", s.name, ErrLockTimeout)
+	}
+	return lock, nil
+}
+
+// TryAcquire は待機せずに許可を得ることを試みる
+// 順番待ちがある場合（先に待っているセッションがいる場合）と、すべてのスロットが使用中の場合は、エラーではなく false を返す
+func (s *Semaphore) TryAcquire(ctx context.Context) (*Lock, bool, error) {
+	return s.acquire(ctx, false)
+}
+
+// acquire はひとつの接続で、空いているスロットのロックを取得する
+// blocking: 許可を得られない場合に、順番待ちに並んで待つか
+func (s *Semaphore) acquire(ctx context.Context, blocking bool) (*Lock, bool, error) {
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return nil, false, newLockError("acquire", s.name, ctx.Err())
+	}
+
+	conn, connID, err := s.db.lockConn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// 順番待ちの登録と取り除きは、取得の期限やキャンセルに関係なく行う
+	queueCtx := context.WithoutCancel(ctx)
+	queue := semaphoreQueueName(s.name)
+
+	// 先に待っているセッションがいる場合は、追い越さないようにスロットを確認せずに並ぶ
+	waiting, err := s.db.queueLength(queueCtx, conn, queue)
+	if err != nil {
+		s.db.returnConn(queueCtx, conn, connID, nil)
+		return nil, false, newLockError("acquire", s.name, err)
+	}
+	if waiting == 0 {
+		lock, acquired, err := s.trySlots(ctx, conn, connID, nil)
+		if err != nil || acquired {
+			return lock, acquired, err
+		}
+	}
+	if !blocking {
+		// ロックを取得していないため、接続はプールに戻す
+		s.db.returnConn(queueCtx, conn, connID, nil)
+		return nil, false, nil
+	}
+
+	waiter, err := s.db.enqueueLockWaiter(queueCtx, conn, queue, connID)
+	if err != nil {
+		s.db.returnConn(queueCtx, conn, connID, nil)
+		return nil, false, newLockError("acquire", s.name, err)
+	}
+	for {
+		if _, err := s.db.waitQueueHead(ctx, conn, waiter); err != nil {
+			s.db.leaveQueue(queueCtx, conn, connID, waiter)
+			return nil, false, newLockError("acquire", s.name, err)
+		}
+
+		lock, acquired, err := s.trySlots(ctx, conn, connID, waiter)
+		if err != nil || acquired {
+			return lock, acquired, err
+		}
+
+		// 先頭のまま、一定時間待ってからすべてのスロットを確認し直す
+		select {
+		case <-time.After(queuePollInterval):
+		case <-ctx.Done():
+			s.db.leaveQueue(queueCtx, conn, connID, waiter)
+			return nil, false, newLockError("acquire", s.name, ctx.Err())
+		}
+	}
+}
+
+// trySlots はすべてのスロットを待機なしで試し、取得できた場合はハンドルを返す
+// 最初に試すスロットは取得のたびにランダムに選ぶ（どのサーバーのプロセスでも同じ分布になるため、特定のスロットに集中しない）
+// waiter: 順番待ちに並んでいる場合はその番号。スロットを取得したら順番待ちから外れ、次の順番待ちが先頭になる
+// エラーの場合、接続はプールに戻すか破棄している
+func (s *Semaphore) trySlots(ctx context.Context, conn *sql.Conn, connID int64, waiter *lockWaiter) (*Lock, bool, error) {
+	queueCtx := context.WithoutCancel(ctx)
+	start := rand.IntN(s.permits)
+	for i := range s.permits {
+		slot := semaphoreSlotName(s.name, (start+i)%s.permits)
+		key := s.db.LockKey(slot)
+		acquired, err := s.db.waitNamedLock(ctx, conn, connID, key, 0)
+		if err != nil {
+			// 接続は waitNamedLock で破棄済み（番号のロックも解放済み）のため、順番待ちは別の接続から取り除く
+			if waiter != nil {
+				s.db.removeLockWaiter(queueCtx, waiter)
+			}
+			return nil, false, newLockError("acquire", s.name, err)
+		}
+		if !acquired {
+			continue
+		}
+
+		if waiter != nil {
+			if err := waiter.leave(queueCtx, conn); err != nil {
+				s.db.releasePartialLocks(conn, connID, []string{slot, waiter.name}, []string{key, waiter.key})
+				return nil, false, newLockError("acquire", s.name, err)
+			}
+		}
+		lock, err := s.db.newAcquiredLock(ctx, conn, connID, slot, key)
+		if err != nil {
+			return nil, false, err
+		}
+		return lock, true, nil
+	}
+	return nil, false, nil
+}
+
+// Holders はスロットごとの保持者のセッションIDを返す（保持者がいないスロットは0）
+func (s *Semaphore) Holders(ctx context.Context) ([]int64, error) {
+	holders := make([]int64, s.permits)
+	for i := range s.permits {
+		connID, used, err := s.db.IsUsedLock(ctx, semaphoreSlotName(s.name, i))
+		if err != nil {
+			return nil, err
+		}
+		if used {
+			holders[i] = connID
+		}
+	}
+	return holders, nil
+}
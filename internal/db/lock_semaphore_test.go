@@ -0,0 +1,125 @@
+package db
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitQueueLength は順番待ちに並んでいる数が want になるまで待つ
+func waitQueueLength(t *testing.T, db *DB, queue string, want int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var n int
+		query := "SELECT COUNT(*) FROM lock_queue WHERE lock_name = ?"
+		if err := db.QueryRowContext(context.Background(), query, db.LockKey(queue)).Scan(&n); err != nil {
+			t.Fatalf("failed to get lock queue length: %v", err)
+		}
+		if n == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("lock queue %s has %d waiters, want %d", queue, n, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSemaphoreLimitsHolders(t *testing.T) {
+	db := openTestDB(t)
+	sem, err := db.Semaphore(testLockName(t), 2)
+	if err != nil {
+		t.Fatalf("failed to create semaphore: %v", err)
+	}
+
+	const clients = 6
+	var holders, maxHolders atomic.Int32
+	var wg sync.WaitGroup
+	errs := make(chan error, clients)
+	for range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			lock, err := sem.Acquire(ctx)
+			if err != nil {
+				errs <- err
+				return
+			}
+			n := holders.Add(1)
+			for {
+				m := maxHolders.Load()
+				if n <= m || maxHolders.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			time.Sleep(200 * time.Millisecond)
+			holders.Add(-1)
+			lock.Release(context.Background())
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("failed to acquire permit: %v", err)
+	}
+	if got := maxHolders.Load(); got > 2 {
+		t.Errorf("max holders = %d, want at most 2", got)
+	}
+}
+
+func TestSemaphoreGrantsInQueueOrder(t *testing.T) {
+	db := openTestDB(t)
+	name := testLockName(t)
+	sem, err := db.Semaphore(name, 1)
+	if err != nil {
+		t.Fatalf("failed to create semaphore: %v", err)
+	}
+
+	holder, err := sem.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("failed to acquire permit: %v", err)
+	}
+
+	// 順番待ちに1つずつ並べ、並んだ順に許可を得ることを確認する
+	const waiters = 4
+	order := make(chan int, waiters)
+	for i := range waiters {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			lock, err := sem.Acquire(ctx)
+			if err != nil {
+				t.Errorf("waiter %d failed to acquire permit: %v", i, err)
+				order <- -1
+				return
+			}
+			order <- i
+			time.Sleep(50 * time.Millisecond)
+			lock.Release(context.Background())
+		}()
+		waitQueueLength(t, db, semaphoreQueueName(name), i+1)
+	}
+
+	// 順番待ちがある間は、スロットが空いていても待機なしの取得は追い越さない
+	if err := holder.Release(context.Background()); err != nil {
+		t.Fatalf("failed to release permit: %v", err)
+	}
+	if lock, acquired, err := sem.TryAcquire(context.Background()); err != nil {
+		t.Fatalf("failed to try permit: %v", err)
+	} else if acquired {
+		lock.Release(context.Background())
+		t.Error("TryAcquire overtook queued waiters")
+	}
+
+	for want := range waiters {
+		if got := <-order; got != want {
+			t.Errorf("waiter %d acquired the permit in position %d", got, want)
+		}
+	}
+	waitQueueLength(t, db, semaphoreQueueName(name), 0)
+}
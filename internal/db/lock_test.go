@@ -0,0 +1,34 @@
+package db
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSortLockNames(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []string
+		want  []string
+	}{
+		{"empty", []string{}, []string{}},
+		{"sorted", []string{"a", "b", "c"}, []string{"a", "b", "c"}},
+		{"unsorted", []string{"product3", "product1", "product2"}, []string{"product1", "product2", "product3"}},
+		{"duplicates", []string{"b", "a", "b", "a"}, []string{"a", "b"}},
+		// バイト順で並べるため、大文字は小文字より前になる
+		{"byte order", []string{"b", "B", "a#w", "a"}, []string{"B", "a", "a#w", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := slices.Clone(tt.input)
+			got := sortLockNames(input)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("sortLockNames(%v) = %v, want %v", tt.input, got, tt.want)
+			}
+			// 引数のスライスは変更しない
+			if !slices.Equal(input, tt.input) {
+				t.Errorf("sortLockNames modified its argument: %v, want %v", input, tt.input)
+			}
+		})
+	}
+}
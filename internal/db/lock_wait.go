@@ -0,0 +1,120 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+)
+
+// lockWaitKillGrace は期限切れ後、GET_LOCK 自身のタイムアウトで戻るのを待つ猶予
+// この時間を過ぎても戻らない場合は KILL QUERY で待機を打ち切る
+const lockWaitKillGrace = 1 * time.Second
+
+// killTimeout は KILL QUERY を発行する際のタイムアウト
+const killTimeout = 5 * time.Second
+
+// lockWaitSeconds はコンテキストの期限から GET_LOCK に渡すタイムアウト（秒）を求める
+// 期限が設定されていない場合は無期限（-1）、期限を過ぎている場合は待機なし（0）を返す
+func lockWaitSeconds(ctx context.Context) int {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return -1
+	}
+	remaining := time.Until(deadline)
+	if remaining <= 0 {
+		return 0
+	}
+	return int(math.Ceil(remaining.Seconds()))
+}
+
+// LockWaitContext はロック待ちのタイムアウト（秒）を期限とするコンテキストを返す
+// タイムアウトが負の場合は期限を設定しない（無期限に待つ）
+func LockWaitContext(ctx context.Context, timeout int) (context.Context, context.CancelFunc) {
+	if timeout < 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+}
+
+// getLockResult は GET_LOCK の実行結果
+type getLockResult struct {
+	acquired bool
+	err      error
+}
+
+// waitNamedLock は conn のセッションで GET_LOCK を実行し、コンテキストを監視しながら結果を待つ
+// コンテキストがキャンセルされた場合は、別の接続から KILL QUERY を発行して待機を打ち切る
+// エラーを返す場合はロックの状態が不明なため conn を破棄する（途中でロックを取得していても接続ごと解放される）
+// そのため、エラー時に呼び出し元は conn を使用してはならない
+func (db *DB) waitNamedLock(ctx context.Context, conn *sql.Conn, connID int64, lockName string, wait int) (bool, error) {
+	done := make(chan getLockResult, 1)
+	go func() {
+		// GET_LOCK は 1=取得成功, 0=タイムアウト, NULL=エラー（スレッドのKILLなど）を返す
+		var result sql.NullInt64
+		// キャンセル時の処理はこの関数で行うため、ドライバにはキャンセルを伝えない
+		err := conn.QueryRowContext(context.WithoutCancel(ctx), "SELECT GET_LOCK(?, ?)", lockName, wait).Scan(&result)
+		if err == nil && !result.Valid {
+			err = ErrLockKilled
+		}
+		done <- getLockResult{acquired: result.Int64 == 1, err: err}
+	}()
+
+	select {
+	case r := <-done:
+		return finishWait(conn, r)
+	case <-ctx.Done():
+	}
+
+	// 期限切れの場合は GET_LOCK 自身のタイムアウトで戻るのを少し待つ
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		select {
+		case r := <-done:
+			return finishWait(conn, r)
+		case <-time.After(lockWaitKillGrace):
+		}
+	}
+
+	// 待機中のクエリを打ち切り、接続を破棄する
+	if err := db.killQuery(connID); err != nil {
+		log.Printf("Failed to kill lock wait (connection ID: %d, lock: %s): %v", connID, lockName, err)
+	}
+	<-done
+	discardConn(conn)
+
+	return false, ctx.Err()
+}
+
+// finishWait は GET_LOCK の結果を返す。エラーの場合は接続を破棄する
+func finishWait(conn *sql.Conn, r getLockResult) (bool, error) {
+	if r.err != nil {
+		discardConn(conn)
+		return false, r.err
+	}
+	return r.acquired, nil
+}
+
+// killQuery は別の接続から指定されたセッションで実行中のクエリを打ち切る
+func (db *DB) killQuery(connID int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), killTimeout)
+	defer cancel()
+
+	// KILL はプレースホルダを使えないため、数値を直接埋め込む
+	if _, err := db.ExecContext(ctx, fmt.Sprintf("KILL QUERY %d", connID)); err != nil {
+		return fmt.Errorf("failed to kill query: %w", err)
+	}
+	return nil
+}
+
+// discardConn は接続をプールに戻さずに閉じる
+// 名前付きロックを保持している可能性がある接続を再利用させないために使用する
+func discardConn(conn *sql.Conn) {
+	conn.Raw(func(interface{}) error {
+		return driver.ErrBadConn
+	})
+	conn.Close()
+}
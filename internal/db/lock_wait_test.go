@@ -0,0 +1,54 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLockWaitSeconds(t *testing.T) {
+	tests := []struct {
+		name string
+		// remaining はコンテキストの期限までの時間（0 の場合は期限を設定しない）
+		remaining time.Duration
+		want      int
+	}{
+		{"no deadline", 0, -1},
+		{"expired", -time.Second, 0},
+		{"exact seconds", 3 * time.Second, 3},
+		{"rounds up", 1500 * time.Millisecond, 2},
+		{"less than a second", 100 * time.Millisecond, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.remaining != 0 {
+				var cancel context.CancelFunc
+				// time.Until が呼ばれるまでの経過で切り上げの結果が変わらないよう、わずかに余裕を持たせる
+				ctx, cancel = context.WithDeadline(ctx, time.Now().Add(tt.remaining-time.Millisecond))
+				defer cancel()
+			}
+			if got := lockWaitSeconds(ctx); got != tt.want {
+				t.Errorf("lockWaitSeconds() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLockWaitContext(t *testing.T) {
+	ctx, cancel := LockWaitContext(context.Background(), -1)
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("negative timeout sets a deadline")
+	}
+
+	ctx, cancel = LockWaitContext(context.Background(), 5)
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		t.Fatal("positive timeout does not set a deadline")
+	}
+	if remaining := time.Until(deadline); remaining <= 4*time.Second || remaining > 5*time.Second {
+		t.Errorf("deadline in %v, want about 5s", remaining)
+	}
+}
@@ -0,0 +1,123 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/example/named-lock/internal/config"
+	"github.com/go-sql-driver/mysql"
+)
+
+// openTestDB は環境変数 MYSQL_DSN の MySQL に接続する
+// MYSQL_DSN が設定されていない場合はテストをスキップする
+// 接続先以外は設定の既定値を使い、サーバーと同じく NewDB で作成する
+// 例: MYSQL_DSN='user:password@tcp(localhost:3333)/locktest?parseTime=true'
+func openTestDB(t *testing.T) *DB {
+	t.Helper()
+	dsn := os.Getenv("MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("MYSQL_DSN is not set")
+	}
+	dsnCfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		t.Fatalf("failed to parse MYSQL_DSN: %v", err)
+	}
+	host, port, err := net.SplitHostPort(dsnCfg.Addr)
+	if err != nil {
+		t.Fatalf("failed to parse MYSQL_DSN address: %v", err)
+	}
+
+	cfg := config.NewConfig().DB
+	cfg.Host = host
+	cfg.Port = port
+	cfg.User = dsnCfg.User
+	cfg.Password = dsnCfg.Passwd
+	cfg.DBName = dsnCfg.DBName
+	db, err := NewDB(&cfg)
+	if err != nil {
+		t.Fatalf("failed to connect to database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// testLockName はテストの実行ごとに重複しないロック名を返す
+func testLockName(t *testing.T) string {
+	return fmt.Sprintf("test/%s/%d", t.Name(), time.Now().UnixNano())
+}
+
+// waitPoolIdle はプールの使用中の接続が 0 になるまで待ち、最後の使用中の接続数を返す
+// 破棄した接続がプールから外れるまでのわずかな遅れを許容する
+func waitPoolIdle(db *DB) int {
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		inUse := db.Stats().InUse
+		if inUse == 0 || time.Now().After(deadline) {
+			return inUse
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestAcquireLockTimeoutsDoNotLeakConnections(t *testing.T) {
+	db := openTestDB(t)
+	lockName := testLockName(t)
+
+	incidents := db.HeldLockIncidents()
+	holder, err := db.AcquireLock(context.Background(), lockName)
+	if err != nil {
+		t.Fatalf("failed to acquire lock: %v", err)
+	}
+
+	// 期限切れ（GET_LOCK 自身のタイムアウト）とキャンセル（KILL QUERY）の両方の経路を同時に発生させる
+	const waiters = 20
+	errs := make(chan error, waiters)
+	for i := 0; i < waiters; i++ {
+		go func(i int) {
+			var ctx context.Context
+			var cancel context.CancelFunc
+			if i%2 == 0 {
+				ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+			} else {
+				ctx, cancel = context.WithCancel(context.Background())
+				time.AfterFunc(200*time.Millisecond, cancel)
+			}
+			defer cancel()
+
+			lock, err := db.AcquireLock(ctx, lockName)
+			if err != nil {
+				errs <- nil
+				return
+			}
+			lock.Release(context.Background())
+			errs <- fmt.Errorf("acquired lock %s held by another session", lockName)
+		}(i)
+	}
+	for i := 0; i < waiters; i++ {
+		if err := <-errs; err != nil {
+			t.Error(err)
+		}
+	}
+
+	if err := holder.Release(context.Background()); err != nil {
+		t.Fatalf("failed to release lock: %v", err)
+	}
+	if inUse := waitPoolIdle(db); inUse != 0 {
+		t.Errorf("Stats().InUse = %d after timeouts, want 0", inUse)
+	}
+	if got := db.HeldLockIncidents(); got != incidents {
+		t.Errorf("HeldLockIncidents() = %d after timeouts, want %d", got, incidents)
+	}
+
+	status, err := db.GetLockStatus(context.Background(), lockName)
+	if err != nil {
+		t.Fatalf("failed to get lock status: %v", err)
+	}
+	if status.IsLocked() {
+		t.Errorf("lock %s is still held by session %d", lockName, status.OwnerSessionID)
+	}
+}
@@ -0,0 +1,136 @@
+package election
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/example/named-lock/internal/config"
+	"github.com/example/named-lock/internal/db"
+)
+
+// ErrNoLeader はリーダーが選ばれていない（辞任した、または接続が切断されてリーダーを失った）ことを表す
+var ErrNoLeader = errors.New("no leader elected")
+
+// Leader はリーダーを表す構造体
+type Leader struct {
+	// Name は選出の名前
+	Name        string
+	CandidateID string
+	// SessionID はリーダーのロックを保持している接続のセッションID
+	SessionID int64
+	// Term はリーダーの任期（選ばれるたびに増える。同じ候補者が選ばれ直した場合も異なる）
+	Term      int64
+	ElectedAt time.Time
+}
+
+// Elector は名前付きロックでリーダーを選出する
+// 選出の名前ごとにひとつのロック（<名前>#leader）を使い、ロックを保持している候補者をリーダーとする
+// リーダーの候補者IDはロックを保持している接続で election_leaders テーブルに書き込み、
+// 他のプロセスからは IS_USED_LOCK の結果とメタデータの接続のセッションIDが一致する場合にリーダーとみなす
+type Elector struct {
+	db *db.DB
+	// observeInterval は Observe でリーダーを確認する間隔
+	observeInterval time.Duration
+}
+
+// NewElector は新しい Elector を作成する
+func NewElector(database *db.DB, cfg *config.ElectionConfig) *Elector {
+	interval := cfg.ObserveInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	return &Elector{db: database, observeInterval: interval}
+}
+
+// Campaign はリーダーに選ばれるまで待ち、リーダーの地位を返す
+// 現在のリーダーが辞任するか、リーダーの接続が切断された時点で、待っている候補者のひとりが選ばれる
+// 待機のタイムアウトはコンテキストの期限から求める（期限がない場合は選ばれるまで待つ）
+// 選ばれた候補者は、メタデータを書き込んでから戻る（Campaign が戻る前に Leader で参照されることはない）
+func (e *Elector) Campaign(ctx context.Context, name string, candidateID string) (*Leadership, error) {
+	if err := db.CheckBaseName(name); err != nil {
+		return nil, err
+	}
+	lock, err := e.db.AcquireLock(ctx, db.ElectionLockName(name))
+	if err != nil {
+		return nil, err
+	}
+
+	leader := &db.ElectionLeader{
+		Name:         name,
+		CandidateID:  candidateID,
+		ConnectionID: lock.ConnectionID(),
+		Term:         lock.FenceToken(),
+		ElectedAt:    time.Now(),
+	}
+	// 選ばれた後のメタデータの書き込みは、待機の期限やキャンセルに関係なく行う
+	if err := writeLeader(context.WithoutCancel(ctx), lock, func(tx *db.Tx) error {
+		return tx.UpsertElectionLeader(leader)
+	}); err != nil {
+		lock.Release(context.WithoutCancel(ctx))
+		return nil, fmt.Errorf("failed to announce leader: %w", err)
+	}
+
+	log.Printf("Elected leader of %s: %s (session ID: %d, term: %d)", name, candidateID, leader.ConnectionID, leader.Term)
+	return &Leadership{lock: lock, leader: newLeader(leader)}, nil
+}
+
+// Leader は現在のリーダーを返す
+// リーダーのロックを保持している接続（IS_USED_LOCK）と、メタデータに書き込まれた接続が一致しない場合は ErrNoLeader を返す
+// （選ばれた候補者がメタデータを書き込むまでの短い間も、リーダーはいないものとする）
+func (e *Elector) Leader(ctx context.Context, name string) (*Leader, error) {
+	leader, _, err := e.resolve(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if leader == nil {
+		return nil, ErrNoLeader
+	}
+	return leader, nil
+}
+
+// resolve は現在のリーダーと、最後に書き込まれたメタデータを返す
+// リーダーがいない場合、リーダーは nil（メタデータの行がない場合はメタデータも nil）
+func (e *Elector) resolve(ctx context.Context, name string) (*Leader, *db.ElectionLeader, error) {
+	record, err := e.db.GetElectionLeader(ctx, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	connID, used, err := e.db.IsUsedLock(ctx, db.ElectionLockName(name))
+	if err != nil {
+		return nil, nil, err
+	}
+	if !used || record == nil || record.ConnectionID != connID {
+		return nil, record, nil
+	}
+	return newLeader(record), record, nil
+}
+
+// newLeader はメタデータからリーダーを作成する
+func newLeader(record *db.ElectionLeader) *Leader {
+	return &Leader{
+		Name:        record.Name,
+		CandidateID: record.CandidateID,
+		SessionID:   record.ConnectionID,
+		Term:        record.Term,
+		ElectedAt:   record.ElectedAt,
+	}
+}
+
+// writeLeader はリーダーのロックを保持している接続のトランザクションでメタデータを書き込む
+func writeLeader(ctx context.Context, lock *db.Lock, fn func(tx *db.Tx) error) error {
+	tx, err := lock.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
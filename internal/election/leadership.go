@@ -0,0 +1,62 @@
+package election
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/example/named-lock/internal/db"
+)
+
+// Leadership はリーダーの地位を表す構造体
+// リーダーのロックを保持している接続を持ち、Resign するまでリーダーであり続ける
+// 接続が切断されるなどしてロックを失った場合は Lost が閉じられる（他の候補者が選ばれる可能性がある）
+type Leadership struct {
+	lock   *db.Lock
+	leader *Leader
+}
+
+// Leader は自分のリーダーとしての情報を返す
+func (l *Leadership) Leader() Leader {
+	return *l.leader
+}
+
+// Lost はリーダーのロックを失ったことを検知した時点で閉じられるチャネルを返す
+// 辞任した場合は閉じられない
+func (l *Leadership) Lost() <-chan struct{} {
+	return l.lock.Lost()
+}
+
+// WithContext はリーダーの地位を失った時点でもキャンセルされる、parent から派生したコンテキストを返す
+// リーダーとしてのみ実行する処理に使い、地位を失った場合に処理を打ち切る
+// 地位を失った場合の context.Cause は db.ErrLockLost を含むエラーになる
+func (l *Leadership) WithContext(parent context.Context) (context.Context, context.CancelFunc) {
+	return l.lock.WithContext(parent)
+}
+
+// BeginTx はリーダーのロックを保持している接続でトランザクションを開始する
+// トランザクションはリーダーの任期（フェンシングトークン）を持ち、地位を失った後の書き込みを検知できる
+func (l *Leadership) BeginTx(ctx context.Context) (*db.Tx, error) {
+	return l.lock.BeginTx(ctx)
+}
+
+// Resign はリーダーを辞任する
+// 自分の任期のメタデータを削除してからロックを解放するため、Observe では辞任（EventResigned）として通知される
+// メタデータを削除できなかった場合もロックは解放する（Observe ではリーダーを失った（EventLost）として通知される）
+func (l *Leadership) Resign(ctx context.Context) error {
+	var deleteErr error
+	if l.lock.LostErr() == nil {
+		deleteErr = writeLeader(ctx, l.lock, func(tx *db.Tx) error {
+			return tx.DeleteElectionLeader(l.leader.Name, l.leader.Term)
+		})
+		if deleteErr != nil {
+			log.Printf("Warning: failed to remove leader of %s: %v", l.leader.Name, deleteErr)
+		}
+	}
+
+	releaseErr := l.lock.Release(ctx)
+	if releaseErr == nil && deleteErr == nil {
+		log.Printf("Resigned leader of %s: %s (term: %d)", l.leader.Name, l.leader.CandidateID, l.leader.Term)
+	}
+	return errors.Join(deleteErr, releaseErr)
+}
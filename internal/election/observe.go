@@ -0,0 +1,94 @@
+package election
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// EventType はリーダーの変化の種類
+type EventType string
+
+const (
+	// EventElected は新しいリーダーが選ばれたことを表す（Observe の開始時点のリーダーを含む）
+	EventElected EventType = "elected"
+	// EventResigned はリーダーが辞任したことを表す
+	EventResigned EventType = "resigned"
+	// EventLost はリーダーが辞任せずにリーダーのロックを失ったこと（接続の切断、プロセスの停止など）を表す
+	EventLost EventType = "lost"
+)
+
+// Event はリーダーの変化を表す構造体
+type Event struct {
+	Type EventType
+	Name string
+	// Leader は新しいリーダー（EventResigned、EventLost の場合は nil）
+	Leader *Leader
+	// Previous は変化する前のリーダー（リーダーがいなかった場合は nil）
+	Previous *Leader
+	// At は変化を検知した時刻
+	At time.Time
+}
+
+// Observe はリーダーの変化を通知するチャネルを返す
+// 一定の間隔（ElectionConfig.ObserveInterval）でリーダーを確認し、変化した場合に Event を送る
+// 開始時点でリーダーがいる場合は、最初に EventElected を送る
+// リーダーの接続が切断された場合は、メタデータが残ったままロックが解放されるため EventLost として通知する
+// 間隔より短い間に起きた変化（辞任と次のリーダーの選出など）は、まとめてひとつの Event になる
+// チャネルはコンテキストが終了した時点で閉じられる
+func (e *Elector) Observe(ctx context.Context, name string) <-chan Event {
+	events := make(chan Event)
+	go e.observe(ctx, name, events)
+	return events
+}
+
+// observe はリーダーを確認し、変化を events に送る
+func (e *Elector) observe(ctx context.Context, name string, events chan<- Event) {
+	defer close(events)
+
+	ticker := time.NewTicker(e.observeInterval)
+	defer ticker.Stop()
+
+	var current *Leader
+	for {
+		leader, record, err := e.resolve(ctx, name)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			// 確認できない間は、前回のリーダーのままとみなす
+			log.Printf("Warning: failed to resolve leader of %s: %v", name, err)
+		} else if event, changed := leaderChange(name, current, leader, record != nil); changed {
+			current = leader
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// leaderChange は前回のリーダーと現在のリーダーを比べ、変化した場合に Event を返す
+// recorded: メタデータの行が残っているか（リーダーがいない場合に、辞任か喪失かを判断する）
+func leaderChange(name string, previous *Leader, leader *Leader, recorded bool) (Event, bool) {
+	event := Event{Name: name, Leader: leader, Previous: previous, At: time.Now()}
+	switch {
+	case leader != nil && (previous == nil || previous.Term != leader.Term):
+		event.Type = EventElected
+	case leader == nil && previous != nil && recorded:
+		// 辞任した場合はメタデータを削除するため、行が残っている場合は辞任せずにロックを失った
+		event.Type = EventLost
+	case leader == nil && previous != nil:
+		event.Type = EventResigned
+	default:
+		return Event{}, false
+	}
+	return event, true
+}
@@ -0,0 +1,312 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/example/named-lock/internal/config"
+	"github.com/example/named-lock/internal/db"
+	"github.com/example/named-lock/internal/service"
+	"github.com/labstack/echo/v4"
+	"github.com/samber/do"
+)
+
+// AdminHandler はロックの管理操作に関するHTTPハンドラ
+type AdminHandler struct {
+	adminService *service.AdminService
+	token        string
+}
+
+// NewAdminHandler は新しいAdminHandlerインスタンスを作成する
+func NewAdminHandler(injector *do.Injector) (*AdminHandler, error) {
+	adminService := do.MustInvoke[*service.AdminService](injector)
+	cfg := do.MustInvoke[*config.Config](injector)
+	return &AdminHandler{
+		adminService: adminService,
+		token:        cfg.Admin.Token,
+	}, nil
+}
+
+// AdminLocksResponse は名前付きロック一覧レスポンスの構造体
+type AdminLocksResponse struct {
+	// Source は一覧の取得元（performance_schema または process）
+	Source string `json:"source"`
+	// Warning は performance_schema を使用できなかった理由（source が process の場合のみ）
+	Warning string              `json:"warning,omitempty"`
+	Locks   []AdminLockResponse `json:"locks"`
+}
+
+// AdminLockResponse は名前付きロックの保持者と待機者の構造体
+type AdminLockResponse struct {
+	LockName string                `json:"lock_name"`
+	LockKey  string                `json:"lock_key"`
+	Holder   *LockSessionResponse  `json:"holder,omitempty"`
+	Waiters  []LockSessionResponse `json:"waiters"`
+}
+
+// LockSessionResponse はロックを保持・待機しているセッションの構造体
+type LockSessionResponse struct {
+	SessionID string `json:"session_id"`
+	User      string `json:"user,omitempty"`
+	Host      string `json:"host,omitempty"`
+	// Seconds は現在の状態の経過秒数（待機者の場合は待機時間）
+	Seconds int64 `json:"seconds"`
+}
+
+// ListLocks は名前付きロックの保持者と待機者の一覧を返すハンドラ
+func (h *AdminHandler) ListLocks(c echo.Context) error {
+	snapshot, err := h.adminService.ListLocks(c.Request().Context())
+	if err != nil {
+		return newOperationError(err, "", "")
+	}
+
+	response := AdminLocksResponse{
+		Source:  snapshot.Source,
+		Warning: snapshot.Warning,
+		Locks:   make([]AdminLockResponse, 0, len(snapshot.Locks)),
+	}
+	for _, lock := range snapshot.Locks {
+		item := AdminLockResponse{
+			LockName: lock.LockName,
+			LockKey:  lock.Key,
+			Waiters:  make([]LockSessionResponse, 0, len(lock.Waiters)),
+		}
+		if lock.Holder != nil {
+			holder := newLockSessionResponse(*lock.Holder)
+			item.Holder = &holder
+		}
+		for _, waiter := range lock.Waiters {
+			item.Waiters = append(item.Waiters, newLockSessionResponse(waiter))
+		}
+		response.Locks = append(response.Locks, item)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// newLockSessionResponse はセッションをレスポンスの形式に変換する
+func newLockSessionResponse(session db.LockSession) LockSessionResponse {
+	return LockSessionResponse{
+		SessionID: fmt.Sprintf("%d", session.ConnectionID),
+		User:      session.User,
+		Host:      session.Host,
+		Seconds:   session.Seconds,
+	}
+}
+
+// ForceReleaseRequest は名前付きロックの強制解放リクエストの構造体
+type ForceReleaseRequest struct {
+	LockName string `param:"lockName" json:"-"`
+	// Reason は強制解放の理由（必須。lock_admin_actions テーブルに記録する）
+	Reason string `json:"reason"`
+	// DryRun の場合は所有者の確認と記録のみを行い、KILL は発行しない
+	DryRun bool `json:"dry_run"`
+	// KillQuery の場合は接続を切断せず、実行中のクエリのみを打ち切る（ロックは解放されない）
+	KillQuery bool `json:"kill_query"`
+}
+
+// ForceReleaseResponse は名前付きロックの強制解放レスポンスの構造体
+type ForceReleaseResponse struct {
+	Success  bool   `json:"success"`
+	LockName string `json:"lock_name"`
+	// ActionID は lock_admin_actions テーブルの記録のID
+	ActionID int64 `json:"action_id"`
+	// Action は kill_connection または kill_query
+	Action string `json:"action"`
+	// Result は killed または dry_run
+	Result string              `json:"result"`
+	DryRun bool                `json:"dry_run"`
+	Owner  LockSessionResponse `json:"owner"`
+}
+
+// ForceRelease はロックを保持しているセッションを KILL して強制的に解放するハンドラ
+func (h *AdminHandler) ForceRelease(c echo.Context) error {
+	var req ForceReleaseRequest
+	if err := c.Bind(&req); err != nil {
+		return newBadRequestError(err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	result, err := h.adminService.ForceRelease(c.Request().Context(), service.ForceReleaseRequest{
+		LockName:  req.LockName,
+		Reason:    req.Reason,
+		DryRun:    req.DryRun,
+		QueryOnly: req.KillQuery,
+	})
+	if err != nil {
+		return newOperationError(err, req.LockName, "")
+	}
+
+	response := ForceReleaseResponse{
+		Success:  true,
+		LockName: req.LockName,
+		ActionID: result.ActionID,
+		Action:   result.Action,
+		Result:   result.Result,
+		DryRun:   req.DryRun,
+		Owner:    newLockSessionResponse(result.Owner.Session),
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// JobsResponse は定期実行ジョブ一覧レスポンスの構造体
+type JobsResponse struct {
+	Jobs []JobResponse `json:"jobs"`
+}
+
+// JobResponse は定期実行ジョブの状態の構造体
+type JobResponse struct {
+	Name string `json:"name"`
+	// Spec は実行予定の指定（@every 10m、cron 形式など）
+	Spec string `json:"spec"`
+	// TimeoutSeconds はひとつの実行の制限時間（秒）
+	TimeoutSeconds int `json:"timeout_seconds"`
+	// NextRunAt はこのサーバーでの次の実行予定時刻（スケジューラーが動作していない場合は省略）
+	NextRunAt string `json:"next_run_at,omitempty"`
+	// HolderSessionID はジョブのロックを保持しているセッションID（実行中の場合のみ。他のサーバーを含む）
+	HolderSessionID string          `json:"holder_session_id,omitempty"`
+	LastRun         *JobRunResponse `json:"last_run,omitempty"`
+}
+
+// JobRunResponse は定期実行ジョブの実行記録の構造体
+type JobRunResponse struct {
+	ID          int64  `json:"id"`
+	JobName     string `json:"job_name"`
+	ScheduledAt string `json:"scheduled_at"`
+	StartedAt   string `json:"started_at"`
+	// FinishedAt は終了した場合のみ
+	FinishedAt string `json:"finished_at,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+	// Outcome は running、succeeded、failed のいずれか
+	Outcome string `json:"outcome"`
+	// SessionID はジョブのロックを保持していた接続のセッションID
+	SessionID string `json:"session_id"`
+	Instance  string `json:"instance"`
+	Message   string `json:"message,omitempty"`
+}
+
+// JobRunsRequest は定期実行ジョブの実行記録リクエストの構造体（パスパラメータとクエリパラメータ）
+type JobRunsRequest struct {
+	Name string `param:"name"`
+	// Limit は返す記録の最大件数。省略した場合は20
+	Limit int `query:"limit"`
+}
+
+// JobRunsResponse は定期実行ジョブの実行記録レスポンスの構造体
+type JobRunsResponse struct {
+	JobName string           `json:"job_name"`
+	Runs    []JobRunResponse `json:"runs"`
+}
+
+// ListJobs は定期実行ジョブの一覧を、最後の実行記録とともに返すハンドラ
+func (h *AdminHandler) ListJobs(c echo.Context) error {
+	jobs, err := h.adminService.ListJobs(c.Request().Context())
+	if err != nil {
+		return newOperationError(err, "", "")
+	}
+
+	response := JobsResponse{Jobs: make([]JobResponse, 0, len(jobs))}
+	for _, job := range jobs {
+		item := JobResponse{
+			Name:           job.Name,
+			Spec:           job.Spec,
+			TimeoutSeconds: int(job.Timeout.Seconds()),
+		}
+		if !job.NextRunAt.IsZero() {
+			item.NextRunAt = job.NextRunAt.Format(time.RFC3339)
+		}
+		if job.HolderSessionID != 0 {
+			item.HolderSessionID = fmt.Sprintf("%d", job.HolderSessionID)
+		}
+		if job.LastRun != nil {
+			lastRun := newJobRunResponse(job.LastRun)
+			item.LastRun = &lastRun
+		}
+		response.Jobs = append(response.Jobs, item)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// ListJobRuns は定期実行ジョブの実行記録を新しい順に返すハンドラ
+func (h *AdminHandler) ListJobRuns(c echo.Context) error {
+	req := JobRunsRequest{Limit: 20}
+	if err := c.Bind(&req); err != nil {
+		return newBadRequestError(err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	runs, err := h.adminService.ListJobRuns(c.Request().Context(), req.Name, req.Limit)
+	if err != nil {
+		return newOperationError(err, "", "")
+	}
+
+	response := JobRunsResponse{JobName: req.Name, Runs: make([]JobRunResponse, 0, len(runs))}
+	for _, run := range runs {
+		response.Runs = append(response.Runs, newJobRunResponse(run))
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// newJobRunResponse は実行記録をレスポンスの形式に変換する
+func newJobRunResponse(run *db.JobRun) JobRunResponse {
+	response := JobRunResponse{
+		ID:          run.ID,
+		JobName:     run.JobName,
+		ScheduledAt: run.ScheduledAt.Format(time.RFC3339),
+		StartedAt:   run.StartedAt.Format(time.RFC3339),
+		DurationMs:  run.Duration.Milliseconds(),
+		Outcome:     run.Outcome,
+		SessionID:   fmt.Sprintf("%d", run.ConnectionID),
+		Instance:    run.Instance,
+		Message:     run.Message,
+	}
+	if run.FinishedAt != nil {
+		response.FinishedAt = run.FinishedAt.Format(time.RFC3339)
+	}
+	return response
+}
+
+// authenticate は管理用APIのトークン（Authorization: Bearer <token>）を確認するミドルウェア
+// トークンが設定されていない場合は、管理用APIを使用できない
+func (h *AdminHandler) authenticate(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if h.token == "" {
+			return &APIError{
+				Status:  http.StatusForbidden,
+				Code:    CodeAdminDisabled,
+				Message: "Admin API is disabled: NAMED_LOCK_ADMIN_TOKEN is not set",
+			}
+		}
+
+		token, ok := strings.CutPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) != 1 {
+			c.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+			return &APIError{
+				Status:  http.StatusUnauthorized,
+				Code:    CodeUnauthorized,
+				Message: "Invalid or missing admin token",
+			}
+		}
+		return next(c)
+	}
+}
+
+// RegisterRoutes はルートを登録する
+// 管理用APIはすべてトークンによる認証が必要（認証後、データベースに接続していない場合は 503 を返す）
+func (h *AdminHandler) RegisterRoutes(e *echo.Echo) {
+	admin := e.Group("/api/admin", h.authenticate, requireDatabase(h.adminService.DatabaseAvailable))
+	admin.GET("/locks", h.ListLocks)
+	admin.POST("/locks/:lockName/release", h.ForceRelease)
+	admin.GET("/jobs", h.ListJobs)
+	admin.GET("/jobs/:name/runs", h.ListJobRuns)
+}
@@ -0,0 +1,257 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/example/named-lock/internal/db"
+	"github.com/example/named-lock/internal/scheduler"
+	"github.com/example/named-lock/internal/service"
+	"github.com/labstack/echo/v4"
+)
+
+// エラーコード（ErrorResponse.Code に設定する機械判読用の値）
+const (
+	CodeInvalidRequest    = "invalid_request"
+	CodeValidationFailed  = "validation_failed"
+	CodeNotFound          = "not_found"
+	CodeMethodNotAllowed  = "method_not_allowed"
+	CodeUnauthorized      = "unauthorized"
+	CodeAdminDisabled     = "admin_disabled"
+	CodeLockTimeout       = "lock_timeout"
+	CodeLockBusy          = "lock_busy"
+	CodeLockDeadlock      = "lock_deadlock"
+	CodeLockNameInvalid   = "lock_name_invalid"
+	CodeLockNotHeld       = "lock_not_held"
+	CodeLockKilled        = "lock_killed"
+	CodeLockLost          = "lock_lost"
+	CodeStaleFence        = "stale_fence"
+	CodeForeignConnection = "foreign_connection"
+	CodeCheckoutRejected  = "checkout_rejected"
+	CodeInsufficientStock = "insufficient_stock"
+	CodeTimeout           = "timeout"
+	CodeDBError           = "db_error"
+	CodeDBUnavailable     = "db_unavailable"
+	CodeInternalError     = "internal_error"
+)
+
+// ErrorResponse はすべてのルートで共通のエラーレスポンスの構造体
+// 成功時の LockResponse と同じフィールド名を使うため、クライアントはどちらの形式でも読み取れる
+type ErrorResponse struct {
+	Success   bool   `json:"success"`
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	LockName  string `json:"lock_name,omitempty"`
+	SessionID string `json:"session_id,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+	// Retryable は同じリクエストを再試行すれば成功する可能性があるか（デッドロックなど）
+	Retryable bool `json:"retryable,omitempty"`
+	// CurrentQuantity は在庫不足の場合の、その時点の在庫数（code が insufficient_stock の場合のみ）
+	CurrentQuantity *int `json:"current_quantity,omitempty"`
+	// Fields は検証エラーの詳細（code が validation_failed の場合のみ）
+	Fields []FieldError `json:"fields,omitempty"`
+	// Lines は受け付けられなかった注文明細（code が checkout_rejected の場合のみ）
+	Lines []CheckoutLineError `json:"lines,omitempty"`
+}
+
+// CheckoutLineError は受け付けられなかった注文明細の構造体
+type CheckoutLineError struct {
+	Index       int    `json:"index"`
+	ProductCode string `json:"product_code"`
+	Quantity    int    `json:"quantity"`
+	Available   int    `json:"available"`
+	Reason      string `json:"reason"`
+}
+
+// APIError はハンドラが返すエラーの構造体
+// HTTPErrorHandler で ErrorResponse 形式に変換される
+type APIError struct {
+	Status    int
+	Code      string
+	Message   string
+	LockName  string
+	SessionID string
+	Retryable bool
+	// CurrentQuantity は在庫不足の場合の、その時点の在庫数
+	CurrentQuantity *int
+	Lines           []CheckoutLineError
+	Err             error
+}
+
+// Error はエラーメッセージを返す
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+// Unwrap は元のエラーを返す
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// newBadRequestError はリクエストボディが不正な場合のエラーを作成する
+func newBadRequestError(err error) *APIError {
+	return &APIError{
+		Status:  http.StatusBadRequest,
+		Code:    CodeInvalidRequest,
+		Message: "Invalid request body: " + err.Error(),
+		Err:     err,
+	}
+}
+
+// newLockBusyError は待機なしの取得で、ロックが他のセッションに保持されていた場合のエラーを作成する
+func newLockBusyError(lockName string) *APIError {
+	return &APIError{
+		Status:   http.StatusLocked,
+		Code:     CodeLockBusy,
+		Message:  "Lock is held by another session: " + lockName,
+		LockName: lockName,
+	}
+}
+
+// newCheckoutRejectedError は在庫不足などで注文を受け付けなかった場合のエラーを作成する
+func newCheckoutRejectedError(err *service.CheckoutError) *APIError {
+	lines := make([]CheckoutLineError, 0, len(err.Lines))
+	for _, line := range err.Lines {
+		lines = append(lines, CheckoutLineError{
+			Index:       line.Index,
+			ProductCode: line.ProductCode,
+			Quantity:    line.Quantity,
+			Available:   line.Available,
+			Reason:      line.Reason,
+		})
+	}
+	return &APIError{
+		Status:  http.StatusConflict,
+		Code:    CodeCheckoutRejected,
+		Message: err.Error(),
+		Lines:   lines,
+		Err:     err,
+	}
+}
+
+// newOperationError はサービスの処理に失敗した場合のエラーを作成する
+// エラーの種類からHTTPステータスとエラーコードを決定する
+func newOperationError(err error, lockName string, sessionID string) *APIError {
+	status, code := errorStatus(err)
+	apiErr := &APIError{
+		Status:    status,
+		Code:      code,
+		Message:   "Operation failed: " + err.Error(),
+		LockName:  lockName,
+		SessionID: sessionID,
+		Retryable: db.IsRetryable(err),
+		Err:       err,
+	}
+	var stockErr *service.InsufficientStockError
+	if errors.As(err, &stockErr) {
+		apiErr.CurrentQuantity = &stockErr.Current
+	}
+	return apiErr
+}
+
+// errorStatus はエラーに対応するHTTPステータスとエラーコードを返す
+func errorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, db.ErrLockTimeout):
+		// ロック待ちのタイムアウト
+		return http.StatusRequestTimeout, CodeLockTimeout
+	case errors.Is(err, db.ErrLockDeadlock):
+		return http.StatusConflict, CodeLockDeadlock
+	case errors.Is(err, db.ErrLockNameInvalid):
+		return http.StatusBadRequest, CodeLockNameInvalid
+	case errors.Is(err, db.ErrLockNotHeld):
+		return http.StatusConflict, CodeLockNotHeld
+	case errors.Is(err, db.ErrLockLost):
+		// 保持中にセッションが切断されるなどしてロックを失い、処理を中断した
+		return http.StatusConflict, CodeLockLost
+	case errors.Is(err, db.ErrStaleFence):
+		// ロックを失った後に他のセッションがロックを取得していたため、書き込みを拒否した
+		return http.StatusConflict, CodeStaleFence
+	case errors.Is(err, db.ErrForeignConnection):
+		// ロックを保持している接続がアプリケーションのユーザーのものでないため、強制解放を拒否した
+		return http.StatusForbidden, CodeForeignConnection
+	case errors.Is(err, db.ErrLockKilled):
+		return http.StatusServiceUnavailable, CodeLockKilled
+	case errors.Is(err, service.ErrDatabaseUnavailable):
+		// データベースに接続せずに起動したため、データベースを使う処理を実行できない
+		return http.StatusServiceUnavailable, CodeDBUnavailable
+	case errors.Is(err, service.ErrInvalidQuantity):
+		return http.StatusBadRequest, CodeInvalidRequest
+	case errors.Is(err, scheduler.ErrJobNotFound):
+		return http.StatusNotFound, CodeNotFound
+	case errors.Is(err, service.ErrInsufficientStock), errors.Is(err, db.ErrNegativeStock):
+		return http.StatusConflict, CodeInsufficientStock
+	case errors.Is(err, context.DeadlineExceeded):
+		// ロック取得後の処理が期限内に終わらなかった場合
+		return http.StatusGatewayTimeout, CodeTimeout
+	}
+	return http.StatusInternalServerError, CodeDBError
+}
+
+// HTTPErrorHandler はハンドラやEchoが返したエラーを ErrorResponse 形式で返す
+func HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	response := ErrorResponse{
+		Success:   false,
+		RequestID: c.Response().Header().Get(echo.HeaderXRequestID),
+	}
+	status := http.StatusInternalServerError
+
+	var apiErr *APIError
+	var validationErr *ValidationError
+	var httpErr *echo.HTTPError
+	switch {
+	case errors.As(err, &validationErr):
+		status = http.StatusBadRequest
+		response.Code = CodeValidationFailed
+		response.Message = validationErr.Error()
+		response.Fields = validationErr.Fields
+	case errors.As(err, &apiErr):
+		status = apiErr.Status
+		response.Code = apiErr.Code
+		response.Message = apiErr.Message
+		response.LockName = apiErr.LockName
+		response.SessionID = apiErr.SessionID
+		response.Retryable = apiErr.Retryable
+		response.CurrentQuantity = apiErr.CurrentQuantity
+		response.Lines = apiErr.Lines
+	case errors.As(err, &httpErr):
+		status = httpErr.Code
+		response.Code = httpErrorCode(httpErr.Code)
+		response.Message = http.StatusText(httpErr.Code)
+		if msg, ok := httpErr.Message.(string); ok {
+			response.Message = msg
+		}
+	default:
+		response.Code = CodeInternalError
+		response.Message = err.Error()
+	}
+
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(status)
+	} else {
+		err = c.JSON(status, response)
+	}
+	if err != nil {
+		c.Logger().Error(err)
+	}
+}
+
+// httpErrorCode はEchoが返したHTTPステータスに対応するエラーコードを返す
+func httpErrorCode(status int) string {
+	switch status {
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case http.StatusBadRequest, http.StatusUnsupportedMediaType, http.StatusRequestEntityTooLarge:
+		return CodeInvalidRequest
+	}
+	return CodeInternalError
+}
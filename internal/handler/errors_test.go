@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/example/named-lock/internal/db"
+	"github.com/example/named-lock/internal/service"
+	"github.com/labstack/echo/v4"
+)
+
+func TestErrorStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{"lock timeout", &db.LockError{Op: "acquire", LockName: "a", Err: db.ErrLockTimeout}, http.StatusRequestTimeout, CodeLockTimeout},
+		{"deadlock", &db.LockError{Op: "acquire", LockName: "a", Err: db.ErrLockDeadlock}, http.StatusConflict, CodeLockDeadlock},
+		{"invalid name", &db.LockError{Op: "acquire", LockName: "", Err: db.ErrLockNameInvalid}, http.StatusBadRequest, CodeLockNameInvalid},
+		{"reserved separator", db.CheckBaseName("a#w"), http.StatusBadRequest, CodeLockNameInvalid},
+		{"not held", &db.LockError{Op: "release", LockName: "a", Err: db.ErrLockNotHeld}, http.StatusConflict, CodeLockNotHeld},
+		{"lost", &db.LockError{Op: "hold", LockName: "a", Err: db.ErrLockLost}, http.StatusConflict, CodeLockLost},
+		{"stale fence", fmt.Errorf("failed to update product: %w", db.ErrStaleFence), http.StatusConflict, CodeStaleFence},
+		{"foreign connection", db.ErrForeignConnection, http.StatusForbidden, CodeForeignConnection},
+		{"killed", &db.LockError{Op: "acquire", LockName: "a", Err: db.ErrLockKilled, Cause: context.Canceled}, http.StatusServiceUnavailable, CodeLockKilled},
+		{"invalid quantity", fmt.Errorf("%w: 0", service.ErrInvalidQuantity), http.StatusBadRequest, CodeInvalidRequest},
+		{"insufficient stock", &service.InsufficientStockError{ProductCode: "p", Requested: 2, Current: 1}, http.StatusConflict, CodeInsufficientStock},
+		{"negative stock", db.ErrNegativeStock, http.StatusConflict, CodeInsufficientStock},
+		// ロック待ちの期限切れは LockError に分類されるため、分類されていない期限切れは処理のタイムアウト
+		{"processing timeout", fmt.Errorf("failed to process: %w", context.DeadlineExceeded), http.StatusGatewayTimeout, CodeTimeout},
+		{"db unavailable", service.ErrDatabaseUnavailable, http.StatusServiceUnavailable, CodeDBUnavailable},
+		{"db error", errors.New("connection refused"), http.StatusInternalServerError, CodeDBError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, code := errorStatus(tt.err)
+			if status != tt.wantStatus || code != tt.wantCode {
+				t.Errorf("errorStatus(%v) = %d, %s; want %d, %s", tt.err, status, code, tt.wantStatus, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestNewOperationError(t *testing.T) {
+	deadlock := &db.LockError{Op: "acquire", LockName: "a", Err: db.ErrLockDeadlock}
+	if apiErr := newOperationError(deadlock, "a", "s1"); !apiErr.Retryable {
+		t.Error("deadlock is not reported as retryable")
+	}
+
+	timeout := &db.LockError{Op: "acquire", LockName: "a", Err: db.ErrLockTimeout}
+	if apiErr := newOperationError(timeout, "a", "s1"); apiErr.Retryable {
+		t.Error("timeout is reported as retryable")
+	}
+
+	stockErr := fmt.Errorf("failed to decrement: %w", &service.InsufficientStockError{ProductCode: "p", Requested: 5, Current: 3})
+	apiErr := newOperationError(stockErr, "p", "s1")
+	if apiErr.Retryable {
+		t.Error("insufficient stock is reported as retryable")
+	}
+	if apiErr.CurrentQuantity == nil || *apiErr.CurrentQuantity != 3 {
+		t.Errorf("CurrentQuantity = %v, want 3", apiErr.CurrentQuantity)
+	}
+}
+
+func TestHTTPErrorHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{"api error", newOperationError(&db.LockError{Op: "acquire", LockName: "a", Err: db.ErrLockTimeout}, "a", ""), http.StatusRequestTimeout, CodeLockTimeout},
+		{"lock busy", newLockBusyError("a"), http.StatusLocked, CodeLockBusy},
+		{"bad request", newBadRequestError(errors.New("unexpected EOF")), http.StatusBadRequest, CodeInvalidRequest},
+		{"validation error", &ValidationError{Fields: []FieldError{{Field: "lock_name"}}}, http.StatusBadRequest, CodeValidationFailed},
+		{"echo unauthorized", echo.ErrUnauthorized, http.StatusUnauthorized, CodeUnauthorized},
+		{"echo not found", echo.ErrNotFound, http.StatusNotFound, CodeNotFound},
+		{"echo method not allowed", echo.ErrMethodNotAllowed, http.StatusMethodNotAllowed, CodeMethodNotAllowed},
+		{"echo unsupported media type", echo.ErrUnsupportedMediaType, http.StatusUnsupportedMediaType, CodeInvalidRequest},
+		{"unknown error", errors.New("boom"), http.StatusInternalServerError, CodeInternalError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			rec := httptest.NewRecorder()
+			c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+
+			HTTPErrorHandler(tt.err, c)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			var response ErrorResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to decode body %q: %v", rec.Body.String(), err)
+			}
+			if response.Success || response.Code != tt.wantCode {
+				t.Errorf("response = %+v, want code %s", response, tt.wantCode)
+			}
+		})
+	}
+}
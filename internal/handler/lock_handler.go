@@ -1,9 +1,12 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
+	"github.com/example/named-lock/internal/db"
 	"github.com/example/named-lock/internal/service"
 	"github.com/labstack/echo/v4"
 	"github.com/samber/do"
@@ -26,6 +29,23 @@ func NewLockHandler(injector *do.Injector) (*LockHandler, error) {
 type AcquireLockRequest struct {
 	LockName string `json:"lock_name"`
 	Timeout  int    `json:"timeout"`
+	// TTL はリース期間（秒）。0の場合は既定値を使用する
+	TTL int `json:"ttl"`
+	// Fair の場合は順番待ち（公平モード）で取得する
+	Fair bool `json:"fair"`
+}
+
+// TryLockRequest は待機なしのロック取得リクエストの構造体
+type TryLockRequest struct {
+	LockName string `json:"lock_name"`
+	// TTL はリース期間（秒）。0の場合は既定値を使用する
+	TTL int `json:"ttl"`
+}
+
+// RenewLockRequest はリース延長リクエストの構造体
+type RenewLockRequest struct {
+	// TTL は延長後のリース期間（秒）。0の場合は既定値を使用する
+	TTL int `json:"ttl"`
 }
 
 // AcquireHoldReleaseRequest はロック取得・保持・解放リクエストの構造体
@@ -33,13 +53,25 @@ type AcquireHoldReleaseRequest struct {
 	LockName     string `json:"lock_name"`
 	Timeout      int    `json:"timeout"`
 	HoldDuration int    `json:"hold_duration"`
+	// Fair の場合は順番待ち（公平モード）で取得する
+	Fair bool `json:"fair"`
 }
 
+// 商品ロック取得・処理・解放の処理内容（AcquireProductReleaseRequest.Mode）
+const (
+	// ProductModeAdd は在庫を増やす（既定値）
+	ProductModeAdd = "add"
+	// ProductModeDecrement は在庫を減らす（在庫が足りない場合は減らさない）
+	ProductModeDecrement = "decrement"
+)
+
 // AcquireProductReleaseRequest はロック取得・処理・解放リクエストの構造体
 type AcquireProductReleaseRequest struct {
 	ProductCode string `json:"product_code"`
 	Quantity    int    `json:"quantity"`
 	Timeout     int    `json:"timeout"`
+	// Mode は処理内容（add または decrement）。省略した場合は add
+	Mode string `json:"mode"`
 }
 
 // AcquireOrderReleaseRequest はロック取得・処理・解放リクエストの構造体
@@ -48,11 +80,195 @@ type AcquireOrderReleaseRequest struct {
 	Timeout     int    `json:"timeout"`
 }
 
+// ListOrdersRequest は注文一覧リクエストの構造体（パスパラメータとクエリパラメータ）
+type ListOrdersRequest struct {
+	ProductCode string `param:"productCode"`
+	// Timeout は読み取りロック待ちのタイムアウト（秒）。省略した場合は -1（無期限に待つ）
+	Timeout int `query:"timeout"`
+	// HoldDuration は一覧を取得した後、読み取りロックを保持したまま待機する時間（秒）
+	HoldDuration int `query:"hold_duration"`
+}
+
+// SemaphoreRequest はセマフォの許可の取得・保持・解放リクエストの構造体
+type SemaphoreRequest struct {
+	Name string `json:"name"`
+	// Permits は同時に得られる許可の数（同じ名前には常に同じ値を指定する）
+	Permits int `json:"permits"`
+	Timeout int `json:"timeout"`
+	// HoldDuration は許可を保持する時間（秒）
+	HoldDuration int `json:"hold_duration"`
+	// Try の場合は待機せずに許可を得ることを試みる（すべて使用中の場合は 423 lock_busy）
+	Try bool `json:"try"`
+}
+
+// SemaphoreStatusRequest はセマフォの状態取得リクエストの構造体（パスパラメータとクエリパラメータ）
+type SemaphoreStatusRequest struct {
+	Name    string `param:"name"`
+	Permits int    `query:"permits"`
+}
+
+// CheckoutRequest は複数商品の注文リクエストの構造体
+type CheckoutRequest struct {
+	Lines   []CheckoutLineRequest `json:"lines"`
+	Timeout int                   `json:"timeout"`
+}
+
+// CheckoutLineRequest は注文明細の構造体
+type CheckoutLineRequest struct {
+	ProductCode string `json:"product_code"`
+	Quantity    int    `json:"quantity"`
+}
+
 // LockResponse はロック操作レスポンスの構造体
 type LockResponse struct {
 	Success   bool   `json:"success"`
 	SessionID string `json:"session_id,omitempty"`
-	Message   string `json:"message,omitempty"`
+	LeaseID   string `json:"lease_id,omitempty"`
+	// FenceToken はロックを取得した際に発行したフェンシングトークン（取得のたびに増える）
+	FenceToken int64  `json:"fence_token,omitempty"`
+	ExpiresAt  string `json:"expires_at,omitempty"`
+	Message    string `json:"message,omitempty"`
+	// Queue は順番待ちの統計（公平モードで取得した場合のみ）
+	Queue *QueueStatsResponse `json:"queue,omitempty"`
+}
+
+// QueueStatsResponse は順番待ち（公平モード）の統計の構造体
+type QueueStatsResponse struct {
+	// Ticket は順番待ちの番号（小さいほど先に並んだ）
+	Ticket int64 `json:"ticket"`
+	// Position は順番待ちに並んだ時点で、前に並んでいた順番待ちの数
+	Position int `json:"position"`
+	// Abandoned は前に並んでいた順番待ちのうち、接続が切断されていたため取り除いた数
+	Abandoned int `json:"abandoned"`
+	// WaitedMs は順番待ちに並んでからロックを取得するまでの時間（ミリ秒）
+	WaitedMs int64 `json:"waited_ms"`
+}
+
+// OrdersResponse は注文一覧レスポンスの構造体
+type OrdersResponse struct {
+	Success     bool   `json:"success"`
+	ProductCode string `json:"product_code"`
+	// SessionID は読み取りロックを保持していたセッションID
+	SessionID string          `json:"session_id"`
+	Orders    []OrderResponse `json:"orders"`
+}
+
+// OrderResponse は注文の構造体
+type OrderResponse struct {
+	OrderID     string `json:"order_id"`
+	ProductCode string `json:"product_code"`
+	Quantity    int    `json:"quantity"`
+}
+
+// SemaphoreResponse はセマフォの許可の取得・保持・解放レスポンスの構造体
+type SemaphoreResponse struct {
+	Success bool   `json:"success"`
+	Name    string `json:"name"`
+	// Slot は保持したスロットのロック名
+	Slot      string `json:"slot"`
+	SessionID string `json:"session_id"`
+	// Holders は許可を得た時点で許可を保持していたセッションの数（自分を含む）
+	Holders    int    `json:"holders"`
+	Permits    int    `json:"permits"`
+	AcquiredAt string `json:"acquired_at"`
+	ReleasedAt string `json:"released_at"`
+}
+
+// SemaphoreStatusResponse はセマフォの状態レスポンスの構造体
+type SemaphoreStatusResponse struct {
+	Name    string `json:"name"`
+	Permits int    `json:"permits"`
+	// Holders はスロットごとの保持者のセッションID（保持者がいないスロットは空文字列）
+	Holders []string `json:"holders"`
+	// InUse は許可を保持しているセッションの数
+	InUse int `json:"in_use"`
+}
+
+// LeaseResponse はリース一覧の要素の構造体
+type LeaseResponse struct {
+	LeaseID    string `json:"lease_id"`
+	LockName   string `json:"lock_name"`
+	SessionID  string `json:"session_id"`
+	FenceToken int64  `json:"fence_token"`
+	State      string `json:"state"`
+	AcquiredAt string `json:"acquired_at"`
+	ExpiresAt  string `json:"expires_at"`
+	EndedAt    string `json:"ended_at,omitempty"`
+}
+
+// LockStatusResponse はロック状態レスポンスの構造体
+type LockStatusResponse struct {
+	LockName string `json:"lock_name"`
+	// LockKey はMySQLのロック名（名前空間付き、64文字を超える場合はハッシュ化した名前）
+	LockKey                 string `json:"lock_key"`
+	IsLocked                bool   `json:"is_locked"`
+	OwnerSessionID          string `json:"owner_session_id,omitempty"`
+	CurrentSessionID        string `json:"current_session_id"`
+	IsOwnedByCurrentSession bool   `json:"is_owned_by_current_session"`
+}
+
+// LockOwnerResponse はロック所有者レスポンスの構造体
+type LockOwnerResponse struct {
+	LockName       string `json:"lock_name"`
+	IsUsed         bool   `json:"is_used"`
+	OwnerSessionID string `json:"owner_session_id,omitempty"`
+	// IsOwnedBySession はクエリパラメータ session_id で指定したセッションが保持しているか（指定時のみ）
+	IsOwnedBySession *bool `json:"is_owned_by_session,omitempty"`
+}
+
+// LockFreeResponse はロック空き状況レスポンスの構造体
+type LockFreeResponse struct {
+	LockName string `json:"lock_name"`
+	IsFree   bool   `json:"is_free"`
+}
+
+// ProductResponse は商品情報レスポンスの構造体
+type ProductResponse struct {
+	ProductCode string `json:"product_code"`
+	Quantity    int    `json:"quantity"`
+}
+
+// CheckoutResponse は複数商品の注文レスポンスの構造体
+type CheckoutResponse struct {
+	Success bool                    `json:"success"`
+	Orders  []CheckoutOrderResponse `json:"orders"`
+	Message string                  `json:"message,omitempty"`
+}
+
+// CheckoutOrderResponse は注文明細ごとに作成した注文の構造体
+type CheckoutOrderResponse struct {
+	OrderID     string `json:"order_id"`
+	ProductCode string `json:"product_code"`
+	Quantity    int    `json:"quantity"`
+	Remaining   int    `json:"remaining"`
+}
+
+// PoolStatsResponse はコネクションプール統計レスポンスの構造体
+type PoolStatsResponse struct {
+	OpenConnections int   `json:"open_connections"`
+	InUse           int   `json:"in_use"`
+	Idle            int   `json:"idle"`
+	WaitCount       int64 `json:"wait_count"`
+	MaxIdleClosed   int64 `json:"max_idle_closed"`
+	// HeldLockIncidents はロックを保持したままプールに戻ろうとした接続の数（解放または破棄した）
+	HeldLockIncidents int64 `json:"held_lock_incidents"`
+}
+
+// GetPoolStats はコネクションプールの統計情報を取得するハンドラ
+// ロック取得に失敗した接続がプールに戻っているか（InUseが0に戻るか）の確認に使用する
+func (h *LockHandler) GetPoolStats(c echo.Context) error {
+	stats := h.lockService.GetPoolStats()
+
+	response := PoolStatsResponse{
+		OpenConnections:   stats.OpenConnections,
+		InUse:             stats.InUse,
+		Idle:              stats.Idle,
+		WaitCount:         stats.WaitCount,
+		MaxIdleClosed:     stats.MaxIdleClosed,
+		HeldLockIncidents: h.lockService.HeldLockIncidents(),
+	}
+
+	return c.JSON(http.StatusOK, response)
 }
 
 // GetCurrentSession は現在のセッションIDを取得するハンドラ
@@ -60,11 +276,7 @@ func (h *LockHandler) GetCurrentSession(c echo.Context) error {
 	// 現在のセッションIDを取得
 	sessionID, err := h.lockService.GetCurrentSessionID()
 	if err != nil {
-		// エラーが発生した場合でも、適切な形式でレスポンスを返す
-		return c.JSON(http.StatusOK, map[string]interface{}{
-			"error":   "Failed to get current session ID: " + err.Error(),
-			"success": false,
-		})
+		return newOperationError(err, "", "")
 	}
 
 	// レスポンスを作成
@@ -79,61 +291,281 @@ func (h *LockHandler) GetCurrentSession(c echo.Context) error {
 	return c.JSON(http.StatusOK, response)
 }
 
+// AcquireLock はロックを取得し、解放されるまで保持するハンドラ
+func (h *LockHandler) AcquireLock(c echo.Context) error {
+	var req AcquireLockRequest
+	if err := c.Bind(&req); err != nil {
+		return newBadRequestError(err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	// ロックを取得する（解放はReleaseLockで行う）
+	ttl := time.Duration(req.TTL) * time.Second
+	lease, stats, err := h.lockService.AcquireLock(c.Request().Context(), req.LockName, req.Timeout, ttl, req.Fair)
+	if err != nil {
+		return newOperationError(err, req.LockName, "")
+	}
+
+	// レスポンスを作成
+	response := LockResponse{
+		Success:    true,
+		SessionID:  lease.SessionID,
+		LeaseID:    lease.ID,
+		FenceToken: lease.FenceToken,
+		ExpiresAt:  lease.ExpiresAt.Format(time.RFC3339),
+		Message:    "Lock acquired successfully. Current connection ID: " + lease.SessionID,
+		Queue:      newQueueStatsResponse(stats),
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// TryLock は待機せずにロックの取得を試みるハンドラ
+// 取得できた場合はAcquireLockと同様にリースとして保持し、他のセッションが保持している場合は423を返す
+func (h *LockHandler) TryLock(c echo.Context) error {
+	var req TryLockRequest
+	if err := c.Bind(&req); err != nil {
+		return newBadRequestError(err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	lease, acquired, err := h.lockService.TryLock(c.Request().Context(), req.LockName, time.Duration(req.TTL)*time.Second)
+	if err != nil {
+		return newOperationError(err, req.LockName, "")
+	}
+	if !acquired {
+		return newLockBusyError(req.LockName)
+	}
+
+	// レスポンスを作成
+	response := LockResponse{
+		Success:    true,
+		SessionID:  lease.SessionID,
+		LeaseID:    lease.ID,
+		FenceToken: lease.FenceToken,
+		ExpiresAt:  lease.ExpiresAt.Format(time.RFC3339),
+		Message:    "Lock acquired successfully. Current connection ID: " + lease.SessionID,
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// GetLockOwner はロックを保持しているセッションIDを取得するハンドラ（ロックは取得しない）
+// クエリパラメータ session_id が指定された場合は、そのセッションが保持しているかも返す
+func (h *LockHandler) GetLockOwner(c echo.Context) error {
+	lockName := c.Param("lockName")
+	if err := c.Validate(&LockNameParam{LockName: lockName}); err != nil {
+		return err
+	}
+
+	owner, err := h.lockService.IsUsedLock(c.Request().Context(), lockName)
+	if err != nil {
+		return newOperationError(err, lockName, "")
+	}
+
+	// レスポンスを作成
+	response := LockOwnerResponse{
+		LockName:       lockName,
+		IsUsed:         owner != "",
+		OwnerSessionID: owner,
+	}
+	if sessionID := c.QueryParam("session_id"); sessionID != "" {
+		owned := owner != "" && owner == sessionID
+		response.IsOwnedBySession = &owned
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// IsFreeLock はロックがどのセッションにも保持されていないかを取得するハンドラ（ロックは取得しない）
+func (h *LockHandler) IsFreeLock(c echo.Context) error {
+	lockName := c.Param("lockName")
+	if err := c.Validate(&LockNameParam{LockName: lockName}); err != nil {
+		return err
+	}
+
+	free, err := h.lockService.IsFreeLock(c.Request().Context(), lockName)
+	if err != nil {
+		return newOperationError(err, lockName, "")
+	}
+
+	return c.JSON(http.StatusOK, LockFreeResponse{
+		LockName: lockName,
+		IsFree:   free,
+	})
+}
+
+// RenewLock はリースの期限を延長するハンドラ
+func (h *LockHandler) RenewLock(c echo.Context) error {
+	var req RenewLockRequest
+	if err := c.Bind(&req); err != nil {
+		return newBadRequestError(err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	lease, err := h.lockService.RenewLock(c.Param("leaseID"), time.Duration(req.TTL)*time.Second)
+	if err != nil {
+		return newOperationError(err, "", "")
+	}
+
+	// レスポンスを作成
+	response := LockResponse{
+		Success:    true,
+		SessionID:  lease.SessionID,
+		LeaseID:    lease.ID,
+		FenceToken: lease.FenceToken,
+		ExpiresAt:  lease.ExpiresAt.Format(time.RFC3339),
+		Message:    "Lease renewed successfully",
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// ListLeases は保持中および直近で期限切れになったリースの一覧を返すハンドラ
+func (h *LockHandler) ListLeases(c echo.Context) error {
+	leases := h.lockService.ListLeases()
+
+	response := make([]LeaseResponse, 0, len(leases))
+	for _, lease := range leases {
+		item := LeaseResponse{
+			LeaseID:    lease.ID,
+			LockName:   lease.LockName,
+			SessionID:  lease.SessionID,
+			FenceToken: lease.FenceToken,
+			State:      lease.State,
+			AcquiredAt: lease.AcquiredAt.Format(time.RFC3339),
+			ExpiresAt:  lease.ExpiresAt.Format(time.RFC3339),
+		}
+		if !lease.EndedAt.IsZero() {
+			item.EndedAt = lease.EndedAt.Format(time.RFC3339)
+		}
+		response = append(response, item)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// ReleaseLock はAcquireLockで取得したロックを解放するハンドラ
+// クエリパラメータ lease_id が指定された場合は、リースIDが一致する場合のみ解放する
+func (h *LockHandler) ReleaseLock(c echo.Context) error {
+	lockName := c.Param("lockName")
+	if err := c.Validate(&LockNameParam{LockName: lockName}); err != nil {
+		return err
+	}
+
+	sessionID, err := h.lockService.ReleaseLock(c.Request().Context(), lockName, c.QueryParam("lease_id"))
+	if err != nil {
+		return newOperationError(err, lockName, sessionID)
+	}
+
+	// レスポンスを作成
+	response := LockResponse{
+		Success:   true,
+		SessionID: sessionID,
+		Message:   "Lock released successfully",
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// GetLockStatus はロックの状態を取得するハンドラ
+func (h *LockHandler) GetLockStatus(c echo.Context) error {
+	lockName := c.Param("lockName")
+	if err := c.Validate(&LockNameParam{LockName: lockName}); err != nil {
+		return err
+	}
+
+	status, err := h.lockService.GetLockStatus(c.Request().Context(), lockName)
+	if err != nil {
+		return newOperationError(err, lockName, "")
+	}
+
+	// レスポンスを作成
+	response := LockStatusResponse{
+		LockName:                status.LockName,
+		LockKey:                 h.lockService.LockKey(status.LockName),
+		IsLocked:                status.IsLocked(),
+		CurrentSessionID:        fmt.Sprintf("%d", status.CurrentSessionID),
+		IsOwnedByCurrentSession: status.IsOwnedByCurrentSession(),
+	}
+	if status.IsLocked() {
+		response.OwnerSessionID = fmt.Sprintf("%d", status.OwnerSessionID)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
 // AcquireHoldReleaseLock はロックを取得し、指定された時間保持した後、解放するハンドラ
 func (h *LockHandler) AcquireHoldReleaseLock(c echo.Context) error {
 	var req AcquireHoldReleaseRequest
 	if err := c.Bind(&req); err != nil {
-		// エラーが発生した場合でも、LockResponse形式でレスポンスを返す
-		response := LockResponse{
-			Success: false,
-			Message: "Invalid request body: " + err.Error(),
-		}
-		return c.JSON(http.StatusOK, response)
+		return newBadRequestError(err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
 	}
 
 	// ロックを取得し、保持し、解放する
-	sessionID, err := h.lockService.AcquireHoldReleaseLock(c.Request().Context(), req.LockName, req.Timeout, req.HoldDuration)
+	sessionID, stats, err := h.lockService.AcquireHoldReleaseLock(c.Request().Context(), req.LockName, req.Timeout, req.HoldDuration, req.Fair)
 	if err != nil {
-		// エラーが発生した場合でも、LockResponse形式でレスポンスを返す
-		response := LockResponse{
-			Success:   false,
-			SessionID: sessionID, // エラー時でもセッションIDがある場合は返す
-			Message:   "Operation failed: " + err.Error(),
-		}
-		return c.JSON(http.StatusOK, response)
+		return newOperationError(err, req.LockName, sessionID)
 	}
-	success := true
-
 	// レスポンスを作成
 	response := LockResponse{
-		Success:   success,
+		Success:   true,
 		SessionID: sessionID,
+		Queue:     newQueueStatsResponse(stats),
 	}
 
 	return c.JSON(http.StatusOK, response)
 }
 
+// newQueueStatsResponse は順番待ちの統計をレスポンスの形式に変換する（公平モードでない場合は nil）
+func newQueueStatsResponse(stats *db.QueueStats) *QueueStatsResponse {
+	if stats == nil {
+		return nil
+	}
+	return &QueueStatsResponse{
+		Ticket:    stats.Ticket,
+		Position:  stats.Position,
+		Abandoned: stats.Abandoned,
+		WaitedMs:  stats.Waited.Milliseconds(),
+	}
+}
+
 // AcquireProductReleaseLock はロックを取得し、処理し、解放するハンドラ
 func (h *LockHandler) AcquireProductReleaseLock(c echo.Context) error {
 	var req AcquireProductReleaseRequest
 	if err := c.Bind(&req); err != nil {
-		// エラーが発生した場合でも、LockResponse形式でレスポンスを返す
-		response := LockResponse{
-			Success: false,
-			Message: "Invalid request body: " + err.Error(),
+		return newBadRequestError(err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	if req.Mode == ProductModeDecrement {
+		// ロックを取得し、在庫を減らし、解放する
+		remaining, err := h.lockService.DecrementProductLock(c.Request().Context(), req.ProductCode, req.Quantity, req.Timeout)
+		if err != nil {
+			return newOperationError(err, req.ProductCode, "")
 		}
-		return c.JSON(http.StatusOK, response)
+
+		return c.JSON(http.StatusOK, LockResponse{
+			Success: true,
+			Message: fmt.Sprintf("Process completed successfully for product: %s, decremented: %d, remaining: %d", req.ProductCode, req.Quantity, remaining),
+		})
 	}
 
 	// ロックを取得し、処理し、解放する
 	err := h.lockService.AcquireProductReleaseLock(c.Request().Context(), req.ProductCode, req.Quantity, req.Timeout)
 	if err != nil {
-		// エラーが発生した場合でも、LockResponse形式でレスポンスを返す
-		response := LockResponse{
-			Success: false,
-			Message: "Operation failed: " + err.Error(),
-		}
-		return c.JSON(http.StatusOK, response)
+		return newOperationError(err, req.ProductCode, "")
 	}
 
 	// レスポンスを作成
@@ -145,27 +577,45 @@ func (h *LockHandler) AcquireProductReleaseLock(c echo.Context) error {
 	return c.JSON(http.StatusOK, response)
 }
 
+// GetProduct は商品の在庫数を取得するハンドラ（ロックは取得しない）
+func (h *LockHandler) GetProduct(c echo.Context) error {
+	productCode := c.Param("productCode")
+	if err := c.Validate(&ProductCodeParam{ProductCode: productCode}); err != nil {
+		return err
+	}
+
+	product, err := h.lockService.GetProduct(c.Request().Context(), productCode)
+	if err != nil {
+		return newOperationError(err, "", "")
+	}
+	if product == nil {
+		return &APIError{
+			Status:  http.StatusNotFound,
+			Code:    CodeNotFound,
+			Message: "Product not found: " + productCode,
+		}
+	}
+
+	return c.JSON(http.StatusOK, ProductResponse{
+		ProductCode: product.Code,
+		Quantity:    product.Quantity,
+	})
+}
+
 // AcquireOrderReleaseLock はロックを取得し、処理し、解放するハンドラ
 func (h *LockHandler) AcquireOrderReleaseLock(c echo.Context) error {
 	var req AcquireOrderReleaseRequest
 	if err := c.Bind(&req); err != nil {
-		// エラーが発生した場合でも、LockResponse形式でレスポンスを返す
-		response := LockResponse{
-			Success: false,
-			Message: "Invalid request body: " + err.Error(),
-		}
-		return c.JSON(http.StatusOK, response)
+		return newBadRequestError(err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
 	}
 
 	// ロックを取得し、処理し、解放する
 	err := h.lockService.AcquireOrderReleaseLock(c.Request().Context(), req.ProductCode, req.Timeout)
 	if err != nil {
-		// エラーが発生した場合でも、LockResponse形式でレスポンスを返す
-		response := LockResponse{
-			Success: false,
-			Message: "Operation failed: " + err.Error(),
-		}
-		return c.JSON(http.StatusOK, response)
+		return newOperationError(err, req.ProductCode, "")
 	}
 
 	// レスポンスを作成
@@ -177,10 +627,185 @@ func (h *LockHandler) AcquireOrderReleaseLock(c echo.Context) error {
 	return c.JSON(http.StatusOK, response)
 }
 
+// ListOrders は商品コードの読み取りロックを取得し、注文の一覧を返すハンドラ
+// 同じ商品コードの一覧の取得は同時に実行でき、注文の挿入とは同時に実行しない
+func (h *LockHandler) ListOrders(c echo.Context) error {
+	req := ListOrdersRequest{Timeout: -1}
+	if err := c.Bind(&req); err != nil {
+		return newBadRequestError(err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	orders, sessionID, err := h.lockService.ListOrders(c.Request().Context(), req.ProductCode, req.Timeout, req.HoldDuration)
+	if err != nil {
+		return newOperationError(err, req.ProductCode, sessionID)
+	}
+
+	// レスポンスを作成
+	response := OrdersResponse{
+		Success:     true,
+		ProductCode: req.ProductCode,
+		SessionID:   sessionID,
+		Orders:      make([]OrderResponse, 0, len(orders)),
+	}
+	for _, order := range orders {
+		response.Orders = append(response.Orders, OrderResponse{
+			OrderID:     order.ID,
+			ProductCode: order.Code,
+			Quantity:    order.Quantity,
+		})
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// AcquireHoldReleaseSemaphore はセマフォの許可を得て、指定された時間だけ保持してから解放するハンドラ
+func (h *LockHandler) AcquireHoldReleaseSemaphore(c echo.Context) error {
+	var req SemaphoreRequest
+	if err := c.Bind(&req); err != nil {
+		return newBadRequestError(err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	hold, acquired, err := h.lockService.AcquireHoldReleaseSemaphore(c.Request().Context(), req.Name, req.Permits, req.Timeout, req.HoldDuration, req.Try)
+	if err != nil {
+		return newOperationError(err, req.Name, "")
+	}
+	if !acquired {
+		return newLockBusyError(req.Name)
+	}
+
+	// レスポンスを作成
+	response := SemaphoreResponse{
+		Success:    true,
+		Name:       req.Name,
+		Slot:       hold.Slot,
+		SessionID:  hold.SessionID,
+		Holders:    hold.Holders,
+		Permits:    req.Permits,
+		AcquiredAt: hold.AcquiredAt.Format(time.RFC3339Nano),
+		ReleasedAt: hold.ReleasedAt.Format(time.RFC3339Nano),
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// GetSemaphoreStatus はセマフォのスロットごとの保持者を返すハンドラ
+func (h *LockHandler) GetSemaphoreStatus(c echo.Context) error {
+	var req SemaphoreStatusRequest
+	if err := c.Bind(&req); err != nil {
+		return newBadRequestError(err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	status, err := h.lockService.GetSemaphoreStatus(c.Request().Context(), req.Name, req.Permits)
+	if err != nil {
+		return newOperationError(err, req.Name, "")
+	}
+
+	// レスポンスを作成
+	response := SemaphoreStatusResponse{
+		Name:    status.Name,
+		Permits: status.Permits,
+		Holders: make([]string, 0, len(status.Holders)),
+	}
+	for _, holder := range status.Holders {
+		if holder == 0 {
+			response.Holders = append(response.Holders, "")
+			continue
+		}
+		response.Holders = append(response.Holders, fmt.Sprintf("%d", holder))
+		response.InUse++
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// Checkout は複数商品のロックを取得し、在庫の引き当てと注文の挿入をひとつのトランザクションで行うハンドラ
+func (h *LockHandler) Checkout(c echo.Context) error {
+	var req CheckoutRequest
+	if err := c.Bind(&req); err != nil {
+		return newBadRequestError(err)
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	lines := make([]service.CheckoutLine, 0, len(req.Lines))
+	for _, line := range req.Lines {
+		lines = append(lines, service.CheckoutLine{
+			ProductCode: line.ProductCode,
+			Quantity:    line.Quantity,
+		})
+	}
+
+	// ロックを取得し、処理し、解放する
+	orders, err := h.lockService.Checkout(c.Request().Context(), lines, req.Timeout)
+	if err != nil {
+		var checkoutErr *service.CheckoutError
+		if errors.As(err, &checkoutErr) {
+			return newCheckoutRejectedError(checkoutErr)
+		}
+		return newOperationError(err, "", "")
+	}
+
+	// レスポンスを作成
+	response := CheckoutResponse{
+		Success: true,
+		Orders:  make([]CheckoutOrderResponse, 0, len(orders)),
+		Message: fmt.Sprintf("Checkout completed successfully for %d lines", len(orders)),
+	}
+	for _, order := range orders {
+		response.Orders = append(response.Orders, CheckoutOrderResponse{
+			OrderID:     order.OrderID,
+			ProductCode: order.ProductCode,
+			Quantity:    order.Quantity,
+			Remaining:   order.Remaining,
+		})
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// requireDatabase はデータベースに接続していない場合に、データベースを使うルートを 503 で拒否するミドルウェア
+func requireDatabase(available func() bool) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !available() {
+				return newOperationError(service.ErrDatabaseUnavailable, "", "")
+			}
+			return next(c)
+		}
+	}
+}
+
 // RegisterRoutes はルートを登録する
+// 保持・解放はロックのバックエンドで行うため、データベースに接続していなくても使用できる
+// （公平モードはデータベースを使うため、サービスが ErrDatabaseUnavailable を返す）
 func (h *LockHandler) RegisterRoutes(e *echo.Echo) {
-	e.GET("/api/session", h.GetCurrentSession)
+	dbRequired := requireDatabase(h.lockService.DatabaseAvailable)
+	e.GET("/api/session", h.GetCurrentSession, dbRequired)
+	e.GET("/api/stats/pool", h.GetPoolStats, dbRequired)
+	e.GET("/api/locks", h.ListLeases, dbRequired)
+	e.POST("/api/locks", h.AcquireLock, dbRequired)
+	e.POST("/api/locks/try", h.TryLock, dbRequired)
+	e.POST("/api/locks/:leaseID/renew", h.RenewLock, dbRequired)
+	e.GET("/api/locks/:lockName", h.GetLockStatus, dbRequired)
+	e.GET("/api/locks/:lockName/owner", h.GetLockOwner, dbRequired)
+	e.GET("/api/locks/:lockName/free", h.IsFreeLock, dbRequired)
+	e.DELETE("/api/locks/:lockName", h.ReleaseLock, dbRequired)
 	e.POST("/api/locks/hold-and-release", h.AcquireHoldReleaseLock)
-	e.POST("/api/locks/product", h.AcquireProductReleaseLock)
-	e.POST("/api/locks/order", h.AcquireOrderReleaseLock)
+	e.POST("/api/locks/product", h.AcquireProductReleaseLock, dbRequired)
+	e.POST("/api/locks/order", h.AcquireOrderReleaseLock, dbRequired)
+	e.POST("/api/checkout", h.Checkout, dbRequired)
+	e.GET("/api/products/:productCode", h.GetProduct, dbRequired)
+	e.GET("/api/orders/:productCode", h.ListOrders, dbRequired)
+	e.POST("/api/semaphores/hold-and-release", h.AcquireHoldReleaseSemaphore, dbRequired)
+	e.GET("/api/semaphores/:name", h.GetSemaphoreStatus, dbRequired)
 }
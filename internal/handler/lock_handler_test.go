@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/example/named-lock/internal/config"
+	"github.com/example/named-lock/internal/locker"
+	"github.com/example/named-lock/internal/scheduler"
+	"github.com/example/named-lock/internal/service"
+	"github.com/labstack/echo/v4"
+	"github.com/samber/do"
+)
+
+// newServerWithoutDatabase は cmd/server と同じ依存関係で、MySQLに接続できない状態のサーバーを組み立てる
+func newServerWithoutDatabase(t *testing.T) *echo.Echo {
+	t.Helper()
+	cfg := config.NewConfig()
+	cfg.Locker.HoldReleaseBackend = locker.BackendMemory
+	// 接続できないアドレス
+	cfg.DB.Host = "127.0.0.1"
+	cfg.DB.Port = "1"
+
+	injector := do.New()
+	do.ProvideValue(injector, cfg)
+	do.Provide(injector, locker.NewDatabase)
+	do.Provide(injector, locker.NewBackend)
+	do.Provide(injector, scheduler.NewScheduler)
+	do.Provide(injector, service.NewLockService)
+	do.Provide(injector, service.NewAdminService)
+	do.Provide(injector, NewRequestValidator)
+	do.Provide(injector, NewLockHandler)
+	do.Provide(injector, NewAdminHandler)
+
+	e := echo.New()
+	e.HTTPErrorHandler = HTTPErrorHandler
+	e.Validator = do.MustInvoke[*RequestValidator](injector)
+	do.MustInvoke[*LockHandler](injector).RegisterRoutes(e)
+	do.MustInvoke[*AdminHandler](injector).RegisterRoutes(e)
+	return e
+}
+
+func TestHoldReleaseWithoutDatabase(t *testing.T) {
+	e := newServerWithoutDatabase(t)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantCode   string
+	}{
+		{"hold and release", http.MethodPost, "/api/locks/hold-and-release", `{"lock_name":"hold","timeout":1,"hold_duration":0}`, http.StatusOK, ""},
+		{"fair hold and release", http.MethodPost, "/api/locks/hold-and-release", `{"lock_name":"hold","timeout":1,"hold_duration":0,"fair":true}`, http.StatusServiceUnavailable, CodeDBUnavailable},
+		{"session", http.MethodGet, "/api/session", "", http.StatusServiceUnavailable, CodeDBUnavailable},
+		{"lock status", http.MethodGet, "/api/locks/hold", "", http.StatusServiceUnavailable, CodeDBUnavailable},
+		{"unknown route", http.MethodGet, "/api/unknown", "", http.StatusNotFound, CodeNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus || !strings.Contains(rec.Body.String(), tt.wantCode) {
+				t.Errorf("%s %s = %d %s, want %d %s", tt.method, tt.path, rec.Code, rec.Body.String(), tt.wantStatus, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/labstack/echo/v4"
+)
+
+// UnescapePathParams はパスパラメータをデコードするミドルウェア
+// Echoはエスケープされたパス（URL.RawPath）でルーティングし、パスパラメータをデコードせずに渡すため、
+// ロック名の '/' を %2F とエスケープして指定した場合に、ハンドラには %2F のまま渡されてしまう
+// RawPath が空の場合はデコード済みのパスでルーティングしているため、二重にデコードしないよう何もしない
+func UnescapePathParams(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if c.Request().URL.RawPath == "" {
+			return next(c)
+		}
+
+		values := c.ParamValues()
+		unescaped := make([]string, len(values))
+		for i, value := range values {
+			v, err := url.PathUnescape(value)
+			if err != nil {
+				return &APIError{
+					Status:  http.StatusBadRequest,
+					Code:    CodeInvalidRequest,
+					Message: "Invalid path parameter: " + value,
+					Err:     err,
+				}
+			}
+			unescaped[i] = v
+		}
+		c.SetParamValues(unescaped...)
+		return next(c)
+	}
+}
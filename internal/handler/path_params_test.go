@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestUnescapePathParams(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantParam  string
+	}{
+		{"plain", "/api/locks/product123", http.StatusOK, "product123"},
+		{"escaped slash", "/api/locks/tenant%2Fwarehouse%2Fproduct", http.StatusOK, "tenant/warehouse/product"},
+		{"escaped colon", "/api/locks/tenant%3Aproduct", http.StatusOK, "tenant:product"},
+		// RawPath が空の場合はデコード済みのため、二重にデコードしない
+		{"escaped percent", "/api/locks/a%2541", http.StatusOK, "a%41"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.HTTPErrorHandler = HTTPErrorHandler
+			e.Use(UnescapePathParams)
+			var got string
+			e.GET("/api/locks/:lockName", func(c echo.Context) error {
+				got = c.Param("lockName")
+				return c.NoContent(http.StatusOK)
+			})
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body: %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if got != tt.wantParam {
+				t.Errorf("lockName = %q, want %q", got, tt.wantParam)
+			}
+		})
+	}
+}
+
+func TestUnescapePathParamsBind(t *testing.T) {
+	e := echo.New()
+	e.HTTPErrorHandler = HTTPErrorHandler
+	e.Use(UnescapePathParams)
+	var release ForceReleaseRequest
+	e.POST("/api/admin/locks/:lockName/release", func(c echo.Context) error {
+		if err := c.Bind(&release); err != nil {
+			return err
+		}
+		return c.NoContent(http.StatusOK)
+	})
+	var semaphore SemaphoreStatusRequest
+	e.GET("/api/semaphores/:name", func(c echo.Context) error {
+		if err := c.Bind(&semaphore); err != nil {
+			return err
+		}
+		return c.NoContent(http.StatusOK)
+	})
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		got    *string
+		want   string
+	}{
+		{"force release", http.MethodPost, "/api/admin/locks/tenant%2Fproduct/release", &release.LockName, "tenant/product"},
+		{"semaphore status", http.MethodGet, "/api/semaphores/tenant%2Fworkers?permits=3", &semaphore.Name, "tenant/workers"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d (body: %s)", rec.Code, http.StatusOK, rec.Body.String())
+			}
+			if *tt.got != tt.want {
+				t.Errorf("name = %q, want %q", *tt.got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package handler
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/example/named-lock/internal/config"
+	"github.com/samber/do"
+)
+
+// maxProductCodeLength は商品コードの最大文字数（products.code / orders.code の列長）
+const maxProductCodeLength = 50
+
+// maxJobRunsLimit は定期実行ジョブの実行記録を一度に返す件数の上限
+const maxJobRunsLimit = 100
+
+// maxReasonLength は管理操作の理由の最大文字数（lock_admin_actions.reason の列長）
+const maxReasonLength = 1024
+
+// FieldError はフィールド単位の検証エラーを表す構造体
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError はリクエストの検証エラーを表す構造体
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// Error はエラーメッセージを返す
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		messages = append(messages, f.Field+": "+f.Message)
+	}
+	return "validation failed: " + strings.Join(messages, ", ")
+}
+
+// LockNameParam はパスパラメータで指定されたロック名の検証に使用する構造体
+type LockNameParam struct {
+	LockName string
+}
+
+// ProductCodeParam はパスパラメータで指定された商品コードの検証に使用する構造体
+type ProductCodeParam struct {
+	ProductCode string
+}
+
+// RequestValidator はリクエストを検証する（echo.Validator を実装する）
+// DB接続を取得する前に、ロック名・タイムアウト・保持時間・数量の範囲を確認する
+type RequestValidator struct {
+	cfg         config.ValidationConfig
+	maxLeaseTTL int
+	namePattern *regexp.Regexp
+}
+
+// NewRequestValidator は新しいRequestValidatorインスタンスを作成する
+func NewRequestValidator(injector *do.Injector) (*RequestValidator, error) {
+	cfg := do.MustInvoke[*config.Config](injector)
+	namePattern, err := regexp.Compile(cfg.Validation.LockNamePattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid lock name pattern: %w", err)
+	}
+	return &RequestValidator{
+		cfg:         cfg.Validation,
+		maxLeaseTTL: int(cfg.Lock.MaxLeaseTTL.Seconds()),
+		namePattern: namePattern,
+	}, nil
+}
+
+// Validate はリクエストの種類に応じて検証を行う
+func (v *RequestValidator) Validate(i interface{}) error {
+	var errs fieldErrors
+	switch req := i.(type) {
+	case *AcquireLockRequest:
+		v.checkLockName(&errs, "lock_name", req.LockName)
+		v.checkTimeout(&errs, "timeout", req.Timeout)
+		v.checkTTL(&errs, "ttl", req.TTL)
+	case *TryLockRequest:
+		v.checkLockName(&errs, "lock_name", req.LockName)
+		v.checkTTL(&errs, "ttl", req.TTL)
+	case *RenewLockRequest:
+		v.checkTTL(&errs, "ttl", req.TTL)
+	case *AcquireHoldReleaseRequest:
+		v.checkLockName(&errs, "lock_name", req.LockName)
+		v.checkTimeout(&errs, "timeout", req.Timeout)
+		v.checkHoldDuration(&errs, "hold_duration", req.HoldDuration)
+	case *AcquireProductReleaseRequest:
+		v.checkProductCode(&errs, "product_code", req.ProductCode)
+		v.checkTimeout(&errs, "timeout", req.Timeout)
+		v.checkQuantity(&errs, "quantity", req.Quantity)
+		if req.Mode != "" && req.Mode != ProductModeAdd && req.Mode != ProductModeDecrement {
+			errs.add("mode", "must be %s or %s", ProductModeAdd, ProductModeDecrement)
+		}
+	case *AcquireOrderReleaseRequest:
+		v.checkProductCode(&errs, "product_code", req.ProductCode)
+		v.checkTimeout(&errs, "timeout", req.Timeout)
+	case *ListOrdersRequest:
+		v.checkProductCode(&errs, "product_code", req.ProductCode)
+		v.checkTimeout(&errs, "timeout", req.Timeout)
+		v.checkHoldDuration(&errs, "hold_duration", req.HoldDuration)
+	case *SemaphoreRequest:
+		v.checkLockName(&errs, "name", req.Name)
+		v.checkPermits(&errs, "permits", req.Permits)
+		v.checkTimeout(&errs, "timeout", req.Timeout)
+		v.checkHoldDuration(&errs, "hold_duration", req.HoldDuration)
+	case *SemaphoreStatusRequest:
+		v.checkLockName(&errs, "name", req.Name)
+		v.checkPermits(&errs, "permits", req.Permits)
+	case *CheckoutRequest:
+		v.checkCheckoutLines(&errs, "lines", req.Lines)
+		v.checkTimeout(&errs, "timeout", req.Timeout)
+	case *ForceReleaseRequest:
+		v.checkLockName(&errs, "lock_name", req.LockName)
+		v.checkReason(&errs, "reason", req.Reason)
+	case *JobRunsRequest:
+		v.checkLockName(&errs, "name", req.Name)
+		if req.Limit < 1 || req.Limit > maxJobRunsLimit {
+			errs.add("limit", "must be between 1 and %d", maxJobRunsLimit)
+		}
+	case *LockNameParam:
+		v.checkLockName(&errs, "lock_name", req.LockName)
+	case *ProductCodeParam:
+		v.checkProductCode(&errs, "product_code", req.ProductCode)
+	}
+
+	if len(errs) > 0 {
+		return &ValidationError{Fields: errs}
+	}
+	return nil
+}
+
+// fieldErrors は検証中に見つかったフィールドエラーを蓄積する
+type fieldErrors []FieldError
+
+func (e *fieldErrors) add(field string, format string, args ...interface{}) {
+	*e = append(*e, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// checkLockName はロック名が空でなく、長さと文字種が制限内であることを確認する
+func (v *RequestValidator) checkLockName(errs *fieldErrors, field string, name string) {
+	switch {
+	case name == "":
+		errs.add(field, "must not be empty")
+	case utf8.RuneCountInString(name) > v.cfg.MaxLockNameLength:
+		errs.add(field, "must be at most %d characters", v.cfg.MaxLockNameLength)
+	case !v.namePattern.MatchString(name):
+		errs.add(field, "must match %s", v.namePattern.String())
+	}
+}
+
+// checkProductCode は商品コードがロック名として使用でき、列長に収まることを確認する
+func (v *RequestValidator) checkProductCode(errs *fieldErrors, field string, code string) {
+	if utf8.RuneCountInString(code) > maxProductCodeLength {
+		errs.add(field, "must be at most %d characters", maxProductCodeLength)
+		return
+	}
+	v.checkLockName(errs, field, code)
+}
+
+// checkCheckoutLines は注文明細の件数と、各明細の商品コード・数量を確認する
+func (v *RequestValidator) checkCheckoutLines(errs *fieldErrors, field string, lines []CheckoutLineRequest) {
+	switch {
+	case len(lines) == 0:
+		errs.add(field, "must not be empty")
+		return
+	case len(lines) > v.cfg.MaxCheckoutLines:
+		errs.add(field, "must have at most %d lines", v.cfg.MaxCheckoutLines)
+		return
+	}
+	for i, line := range lines {
+		v.checkProductCode(errs, fmt.Sprintf("%s[%d].product_code", field, i), line.ProductCode)
+		v.checkQuantity(errs, fmt.Sprintf("%s[%d].quantity", field, i), line.Quantity)
+	}
+}
+
+// checkReason は管理操作の理由が空でなく、記録できる長さであることを確認する
+func (v *RequestValidator) checkReason(errs *fieldErrors, field string, reason string) {
+	switch {
+	case strings.TrimSpace(reason) == "":
+		errs.add(field, "must not be empty")
+	case utf8.RuneCountInString(reason) > maxReasonLength:
+		errs.add(field, "must be at most %d characters", maxReasonLength)
+	}
+}
+
+// checkTimeout はロック待ちのタイムアウト（秒）が範囲内であることを確認する
+func (v *RequestValidator) checkTimeout(errs *fieldErrors, field string, timeout int) {
+	switch {
+	case timeout < 0 && !v.cfg.AllowInfiniteWait:
+		errs.add(field, "must be between 0 and %d", v.cfg.MaxWaitTimeout)
+	case timeout < -1:
+		errs.add(field, "must be -1 (wait forever) or between 0 and %d", v.cfg.MaxWaitTimeout)
+	case timeout > v.cfg.MaxWaitTimeout:
+		errs.add(field, "must be at most %d", v.cfg.MaxWaitTimeout)
+	}
+}
+
+// checkHoldDuration はロックの保持時間（秒）が範囲内であることを確認する
+func (v *RequestValidator) checkHoldDuration(errs *fieldErrors, field string, holdDuration int) {
+	if holdDuration < 0 || holdDuration > v.cfg.MaxHoldDuration {
+		errs.add(field, "must be between 0 and %d", v.cfg.MaxHoldDuration)
+	}
+}
+
+// checkTTL はリース期間（秒）が範囲内であることを確認する（0は既定値を使用する）
+func (v *RequestValidator) checkTTL(errs *fieldErrors, field string, ttl int) {
+	if ttl < 0 || ttl > v.maxLeaseTTL {
+		errs.add(field, "must be between 0 and %d", v.maxLeaseTTL)
+	}
+}
+
+// checkPermits はセマフォの許可の数が範囲内であることを確認する
+func (v *RequestValidator) checkPermits(errs *fieldErrors, field string, permits int) {
+	if permits < 1 || permits > v.cfg.MaxSemaphorePermits {
+		errs.add(field, "must be between 1 and %d", v.cfg.MaxSemaphorePermits)
+	}
+}
+
+// checkQuantity は数量が正の値であることを確認する
+func (v *RequestValidator) checkQuantity(errs *fieldErrors, field string, quantity int) {
+	if quantity <= 0 {
+		errs.add(field, "must be greater than 0")
+	}
+}
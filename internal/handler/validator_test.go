@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/example/named-lock/internal/config"
+	"github.com/labstack/echo/v4"
+	"github.com/samber/do"
+)
+
+func TestGetProductValidatesProductCode(t *testing.T) {
+	injector := do.New()
+	do.ProvideValue(injector, config.NewConfig())
+	validator, err := NewRequestValidator(injector)
+	if err != nil {
+		t.Fatalf("failed to create validator: %v", err)
+	}
+
+	e := echo.New()
+	e.HTTPErrorHandler = HTTPErrorHandler
+	e.Validator = validator
+	e.Use(UnescapePathParams)
+	// 検証に失敗した場合はサービス（DB）を呼び出さない
+	h := &LockHandler{}
+	e.GET("/api/products/:productCode", h.GetProduct)
+
+	tests := []struct {
+		name string
+		path string
+	}{
+		{"invalid character", "/api/products/product%20123"},
+		{"too long", "/api/products/" + strings.Repeat("x", maxProductCodeLength+1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), CodeValidationFailed) {
+				t.Errorf("GET %s = %d %s, want 400 %s", tt.path, rec.Code, rec.Body.String(), CodeValidationFailed)
+			}
+		})
+	}
+}
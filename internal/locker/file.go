@@ -0,0 +1,198 @@
+package locker
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// filePollInterval は他のセッションが保持しているロックファイルの flock を再び試みる間隔
+// flock の待機はコンテキストで打ち切れないため、待機なしの取得を繰り返す
+const filePollInterval = 50 * time.Millisecond
+
+// maxLockFileNameLength はロック名をそのまま使うロックファイル名の最大長
+// これより長い場合は、ロック名のハッシュをファイル名にする
+const maxLockFileNameLength = 200
+
+// fileBackend はロックファイルの flock のバックエンド
+type fileBackend struct {
+	dir string
+	// nextID はセッションIDの採番に使う
+	nextID atomic.Int64
+}
+
+// fileHold はセッションが保持しているロックファイル
+type fileHold struct {
+	file *os.File
+	// count はセッションが取得した回数
+	count int
+}
+
+// fileLocker はロックファイルの flock のセッション
+// flock はファイルを開いたディスクリプタごとのロックのため、同じプロセスのセッション同士でも排他される
+type fileLocker struct {
+	backend *fileBackend
+	id      string
+	holds   map[string]*fileHold
+	lost    chan struct{}
+	closed  bool
+}
+
+// NewFileBackend はロックファイルの flock のバックエンドを作成する
+// ロックファイルは dir に作成し、解放後も削除しない（削除すると、開いたままのファイルと新しいファイルで排他されなくなるため）
+func NewFileBackend(dir string) (Backend, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create lock directory: %w", err)
+	}
+	return &fileBackend{dir: dir}, nil
+}
+
+// Name はバックエンドの種類を返す
+func (b *fileBackend) Name() string {
+	return BackendFile
+}
+
+// NewLocker は新しいセッションを作成する
+// セッションIDはプロセスIDと、プロセス内の連番を組み合わせる
+func (b *fileBackend) NewLocker(ctx context.Context) (Locker, error) {
+	return &fileLocker{
+		backend: b,
+		id:      fmt.Sprintf("%d-%d", os.Getpid(), b.nextID.Add(1)),
+		holds:   make(map[string]*fileHold),
+		lost:    make(chan struct{}),
+	}, nil
+}
+
+// lockFilePath はロック名のロックファイルのパスを返す
+// ロック名はパスとして使えない文字（/ など）をエスケープし、長い場合はハッシュにする
+func (b *fileBackend) lockFilePath(name string) string {
+	fileName := url.PathEscape(name)
+	if len(fileName) > maxLockFileNameLength {
+		hash := sha256.Sum256([]byte(name))
+		fileName = hex.EncodeToString(hash[:])
+	}
+	return filepath.Join(b.dir, fileName+".lock")
+}
+
+// SessionID はセッションの識別子を返す
+func (l *fileLocker) SessionID() string {
+	return l.id
+}
+
+// Acquire はロックを取得するまで待つ
+func (l *fileLocker) Acquire(ctx context.Context, name string) error {
+	acquired, err := l.acquire(ctx, name, true)
+	if err == nil && !acquired {
+		return waitError(ctx, name)
+	}
+	return err
+}
+
+// TryAcquire は待機せずにロックの取得を試みる
+func (l *fileLocker) TryAcquire(ctx context.Context, name string) (bool, error) {
+	return l.acquire(ctx, name, false)
+}
+
+// acquire はロックファイルを開いて flock を取得する（保持している場合は取得回数を増やす）
+// blocking: 他のセッションが保持している場合に、解放されるまで待つか
+func (l *fileLocker) acquire(ctx context.Context, name string, blocking bool) (bool, error) {
+	if l.closed {
+		return false, closedError("acquire", name)
+	}
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return false, waitError(ctx, name)
+	}
+	if hold, ok := l.holds[name]; ok {
+		hold.count++
+		return true, nil
+	}
+
+	file, err := os.OpenFile(l.backend.lockFilePath(name), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return false, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	for {
+		locked, err := tryFlock(file)
+		if err != nil {
+			file.Close()
+			return false, fmt.Errorf("failed to lock file: %w", err)
+		}
+		if locked {
+			l.holds[name] = &fileHold{file: file, count: 1}
+			// 保持しているセッションを確認できるよう、ロックファイルにセッションIDを書き込む（失敗しても保持は続ける）
+			file.Truncate(0)
+			file.WriteAt([]byte(l.id+"\n"), 0)
+			return true, nil
+		}
+		// 期限切れのコンテキストでも一度は取得を試みる（GET_LOCK(name, 0) と同じ）
+		if !blocking || ctx.Err() != nil {
+			file.Close()
+			if !blocking {
+				return false, nil
+			}
+			return false, waitError(ctx, name)
+		}
+
+		select {
+		case <-time.After(filePollInterval):
+		case <-ctx.Done():
+			file.Close()
+			return false, waitError(ctx, name)
+		}
+	}
+}
+
+// Release はロックを1回だけ解放する
+// 取得回数が0になった時点で flock を解放し、ロックファイルを閉じる
+func (l *fileLocker) Release(ctx context.Context, name string) error {
+	hold, ok := l.holds[name]
+	if l.closed || !ok {
+		return notHeldError(name)
+	}
+	hold.count--
+	if hold.count > 0 {
+		return nil
+	}
+
+	delete(l.holds, name)
+	unlockErr := unlockFile(hold.file)
+	// ファイルを閉じれば flock は解放されるため、解放のエラーはファイルを閉じられなかった場合のみ返す
+	if err := hold.file.Close(); err != nil {
+		return fmt.Errorf("failed to release lock file %q: %w", name, errors.Join(unlockErr, err))
+	}
+	return nil
+}
+
+// IsHeld はこのセッションがロックを保持しているかを返す
+func (l *fileLocker) IsHeld(ctx context.Context, name string) (bool, error) {
+	_, ok := l.holds[name]
+	return ok && !l.closed, nil
+}
+
+// Lost はロックを失った時点で閉じられるチャネルを返す（flock はファイルを閉じるまで失わないため閉じられない）
+func (l *fileLocker) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// Close は保持しているすべてのロックファイルを閉じて、flock を解放する
+func (l *fileLocker) Close(ctx context.Context) error {
+	if l.closed {
+		return nil
+	}
+	var errs []error
+	for name, hold := range l.holds {
+		hold.count = 1
+		if err := l.Release(ctx, name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	l.closed = true
+	return errors.Join(errs...)
+}
@@ -0,0 +1,81 @@
+//go:build unix
+
+package locker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileBackend(t *testing.T) {
+	testBackendSemantics(t, func(t *testing.T) Backend {
+		return newTestFileBackend(t, t.TempDir())
+	})
+}
+
+func TestFileBackendSharesDirectory(t *testing.T) {
+	// 同じディレクトリを使うバックエンド同士（別のプロセスに相当）でも排他される
+	dir := t.TempDir()
+	a := newTestLocker(t, newTestFileBackend(t, dir))
+	b := newTestLocker(t, newTestFileBackend(t, dir))
+
+	mustAcquire(t, a, "shared")
+	if acquired, err := b.TryAcquire(context.Background(), "shared"); err != nil || acquired {
+		t.Fatalf("TryAcquire from another backend = %t, %v; want false, nil", acquired, err)
+	}
+}
+
+func TestLockFilePath(t *testing.T) {
+	dir := t.TempDir()
+	backend := newTestFileBackend(t, dir).(*fileBackend)
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"product123", "product123.lock"},
+		{"tenant/warehouse/product", "tenant%2Fwarehouse%2Fproduct.lock"},
+		{strings.Repeat("x", maxLockFileNameLength+1), ""},
+	}
+	for _, tt := range tests {
+		got := backend.lockFilePath(tt.name)
+		if filepath.Dir(got) != dir {
+			t.Errorf("lockFilePath(%q) = %q, want a file in %q", tt.name, got, dir)
+		}
+		base := filepath.Base(got)
+		if tt.want != "" && base != tt.want {
+			t.Errorf("lockFilePath(%q) = %q, want %q", tt.name, base, tt.want)
+		}
+		if tt.want == "" && len(base) != 64+len(".lock") {
+			t.Errorf("lockFilePath(long name) = %q, want a SHA-256 hex file name", base)
+		}
+	}
+}
+
+func TestFileLockerWritesSessionID(t *testing.T) {
+	dir := t.TempDir()
+	backend := newTestFileBackend(t, dir)
+	l := newTestLocker(t, backend)
+
+	mustAcquire(t, l, "owner")
+	data, err := os.ReadFile(backend.(*fileBackend).lockFilePath("owner"))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if got := strings.TrimSpace(string(data)); got != l.SessionID() {
+		t.Errorf("lock file content = %q, want session id %q", got, l.SessionID())
+	}
+}
+
+// newTestFileBackend は dir にロックファイルを作成するバックエンドを作成する
+func newTestFileBackend(t *testing.T, dir string) Backend {
+	t.Helper()
+	backend, err := NewFileBackend(dir)
+	if err != nil {
+		t.Fatalf("NewFileBackend: %v", err)
+	}
+	return backend
+}
@@ -0,0 +1,21 @@
+//go:build !unix
+
+package locker
+
+import (
+	"errors"
+	"os"
+)
+
+// errFlockUnsupported は flock を使えないプラットフォームであることを表す
+var errFlockUnsupported = errors.New("file locker backend is not supported on this platform")
+
+// tryFlock はこのプラットフォームでは使えない
+func tryFlock(file *os.File) (bool, error) {
+	return false, errFlockUnsupported
+}
+
+// unlockFile はこのプラットフォームでは使えない
+func unlockFile(file *os.File) error {
+	return errFlockUnsupported
+}
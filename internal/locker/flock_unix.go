@@ -0,0 +1,24 @@
+//go:build unix
+
+package locker
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// tryFlock はファイルの排他ロック（flock）を待機せずに取得する
+// 他のディスクリプタが保持している場合は false を返す
+func tryFlock(file *os.File) (bool, error) {
+	err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// unlockFile はファイルの排他ロック（flock）を解放する
+func unlockFile(file *os.File) error {
+	return syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+}
@@ -0,0 +1,115 @@
+package locker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/example/named-lock/internal/config"
+	"github.com/example/named-lock/internal/db"
+	"github.com/samber/do"
+)
+
+// バックエンドの種類（LockerConfig.HoldReleaseBackend）
+const (
+	// BackendMySQL はMySQLの名前付きロック（GET_LOCK）を使う。複数のサーバー間でロックを共有する
+	BackendMySQL = "mysql"
+	// BackendMemory はプロセス内のロックを使う。MySQLなしで動作確認やテストをする場合に使う
+	BackendMemory = "memory"
+	// BackendFile はロックファイルの flock を使う。ひとつのホストで複数のプロセス間でロックを共有する
+	BackendFile = "file"
+)
+
+// Locker はひとつのセッション（ロックを所有する単位。MySQLのバックエンドでは接続）でロックを取得・解放する
+// どのバックエンドも次の動作は同じ
+//   - 同じセッションで同じロック名を取得すると取得回数が増え（再入）、同じ回数だけ解放するまで保持する
+//   - 他のセッションが保持しているロックは取得できず、保持していないロックの解放は db.ErrLockNotHeld を返す
+//   - ロック待ちのタイムアウトはコンテキストの期限から求め、期限切れの場合は db.ErrLockTimeout、
+//     キャンセルされた場合は db.ErrLockKilled を含む *db.LockError を返す（期限がない場合は無期限に待つ）
+//
+// MySQLのセッションと同じく、ひとつの Locker を複数のゴルーチンから同時に使用しない
+type Locker interface {
+	// SessionID はセッションの識別子を返す（MySQLのバックエンドでは最初にロックを取得するまで空）
+	SessionID() string
+	// Acquire はロックを取得するまで待つ
+	Acquire(ctx context.Context, name string) error
+	// TryAcquire は待機せずにロックの取得を試み、他のセッションが保持している場合は false を返す
+	TryAcquire(ctx context.Context, name string) (bool, error)
+	// Release はロックを1回だけ解放する（取得回数を1減らす）
+	Release(ctx context.Context, name string) error
+	// IsHeld はこのセッションがロックを保持しているかを返す
+	IsHeld(ctx context.Context, name string) (bool, error)
+	// Lost はセッションが保持していたロックを失った時点で閉じられるチャネルを返す
+	// （MySQLのバックエンドで接続が切断された場合など。他のバックエンドではプロセスが動いている間は失わない）
+	Lost() <-chan struct{}
+	// Close は保持しているすべてのロックを解放し、セッションを終了する
+	Close(ctx context.Context) error
+}
+
+// Backend はセッション（Locker）を作成する
+type Backend interface {
+	// Name はバックエンドの種類を返す
+	Name() string
+	// NewLocker は新しいセッションを作成する
+	NewLocker(ctx context.Context) (Locker, error)
+}
+
+// UsesMySQL は保持・解放のバックエンド（LockerConfig.HoldReleaseBackend）がMySQLの名前付きロックかを返す
+func UsesMySQL(backend string) bool {
+	return backend == "" || backend == BackendMySQL
+}
+
+// NewDatabase はデータベース接続を作成する
+// 保持・解放のバックエンドがMySQLでない場合は、MySQLに接続できなくても nil を返して起動を続ける
+// （その場合、データベースを使う処理は service.ErrDatabaseUnavailable で失敗し、保持・解放のみを使用できる）
+func NewDatabase(injector *do.Injector) (*db.DB, error) {
+	cfg := do.MustInvoke[*config.Config](injector)
+	database, err := db.NewDB(&cfg.DB)
+	if err != nil {
+		if UsesMySQL(cfg.Locker.HoldReleaseBackend) {
+			return nil, err
+		}
+		log.Printf("Warning: starting without the database; only hold-and-release is available (locker backend: %s): %v", cfg.Locker.HoldReleaseBackend, err)
+		return nil, nil
+	}
+	return database, nil
+}
+
+// NewBackend は設定（LockerConfig.HoldReleaseBackend）に従ってバックエンドを作成する
+func NewBackend(injector *do.Injector) (Backend, error) {
+	cfg := do.MustInvoke[*config.Config](injector)
+	if UsesMySQL(cfg.Locker.HoldReleaseBackend) {
+		return NewMySQLBackend(do.MustInvoke[*db.DB](injector)), nil
+	}
+	switch cfg.Locker.HoldReleaseBackend {
+	case BackendMemory:
+		return NewMemoryBackend(), nil
+	case BackendFile:
+		return NewFileBackend(cfg.Locker.FileDir)
+	}
+	return nil, fmt.Errorf("unknown locker backend: %q", cfg.Locker.HoldReleaseBackend)
+}
+
+// errClosed はセッションを終了した後に操作したことを表す
+var errClosed = errors.New("locker session is closed")
+
+// waitError はロック待ちを打ち切った場合のエラーを返す
+// 期限切れの場合はタイムアウト、キャンセルされた場合は中断として扱う（MySQLのバックエンドと同じ分類）
+func waitError(ctx context.Context, name string) error {
+	kind := db.ErrLockKilled
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		kind = db.ErrLockTimeout
+	}
+	return &db.LockError{Op: "acquire", LockName: name, Err: kind, Cause: ctx.Err()}
+}
+
+// notHeldError は保持していないロックを解放しようとした場合のエラーを返す
+func notHeldError(name string) error {
+	return &db.LockError{Op: "release", LockName: name, Err: db.ErrLockNotHeld}
+}
+
+// closedError は終了したセッションで操作した場合のエラーを返す
+func closedError(op string, name string) error {
+	return &db.LockError{Op: op, LockName: name, Err: db.ErrLockNotHeld, Cause: errClosed}
+}
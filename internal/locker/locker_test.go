@@ -0,0 +1,231 @@
+package locker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/example/named-lock/internal/config"
+	"github.com/example/named-lock/internal/db"
+	"github.com/samber/do"
+)
+
+func TestNewDatabaseIsOptionalWithoutMySQLBackend(t *testing.T) {
+	tests := []struct {
+		backend string
+		wantErr bool
+	}{
+		{BackendMySQL, true},
+		{BackendMemory, false},
+		{BackendFile, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.backend, func(t *testing.T) {
+			cfg := config.NewConfig()
+			cfg.Locker.HoldReleaseBackend = tt.backend
+			// 接続できないアドレス
+			cfg.DB.Host = "127.0.0.1"
+			cfg.DB.Port = "1"
+			injector := do.New()
+			do.ProvideValue(injector, cfg)
+
+			database, err := NewDatabase(injector)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("NewDatabase succeeded without MySQL, want an error")
+				}
+				return
+			}
+			if err != nil || database != nil {
+				t.Fatalf("NewDatabase = %v, %v; want nil, nil", database, err)
+			}
+		})
+	}
+}
+
+// testBackendSemantics はどのバックエンドでも同じであるべき動作（再入、セッションごとの所有、タイムアウトと中断の分類）を確認する
+func testBackendSemantics(t *testing.T, newBackend func(t *testing.T) Backend) {
+	t.Run("reentrant", func(t *testing.T) {
+		ctx := context.Background()
+		backend := newBackend(t)
+		a := newTestLocker(t, backend)
+		b := newTestLocker(t, backend)
+
+		mustAcquire(t, a, "reentrant")
+		mustAcquire(t, a, "reentrant")
+		if err := a.Release(ctx, "reentrant"); err != nil {
+			t.Fatalf("first Release: %v", err)
+		}
+		if held, _ := a.IsHeld(ctx, "reentrant"); !held {
+			t.Fatal("lock released after one of two releases")
+		}
+		if acquired, err := b.TryAcquire(ctx, "reentrant"); err != nil || acquired {
+			t.Fatalf("other session TryAcquire = %t, %v; want false, nil", acquired, err)
+		}
+
+		if err := a.Release(ctx, "reentrant"); err != nil {
+			t.Fatalf("second Release: %v", err)
+		}
+		if held, _ := a.IsHeld(ctx, "reentrant"); held {
+			t.Fatal("lock still held after releasing as many times as acquired")
+		}
+		if err := a.Release(ctx, "reentrant"); !errors.Is(err, db.ErrLockNotHeld) {
+			t.Fatalf("extra Release = %v, want ErrLockNotHeld", err)
+		}
+		if acquired, err := b.TryAcquire(ctx, "reentrant"); err != nil || !acquired {
+			t.Fatalf("other session TryAcquire after release = %t, %v; want true, nil", acquired, err)
+		}
+	})
+
+	t.Run("per-session ownership", func(t *testing.T) {
+		ctx := context.Background()
+		backend := newBackend(t)
+		a := newTestLocker(t, backend)
+		b := newTestLocker(t, backend)
+
+		mustAcquire(t, a, "owned")
+		if a.SessionID() == b.SessionID() {
+			t.Fatalf("sessions share the id %q", a.SessionID())
+		}
+		if err := b.Release(ctx, "owned"); !errors.Is(err, db.ErrLockNotHeld) {
+			t.Fatalf("Release by other session = %v, want ErrLockNotHeld", err)
+		}
+		if held, _ := b.IsHeld(ctx, "owned"); held {
+			t.Fatal("IsHeld reports a lock held by another session")
+		}
+		if held, _ := a.IsHeld(ctx, "owned"); !held {
+			t.Fatal("owner lost the lock after another session tried to release it")
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		backend := newBackend(t)
+		a := newTestLocker(t, backend)
+		b := newTestLocker(t, backend)
+
+		mustAcquire(t, a, "timeout")
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		err := b.Acquire(ctx, "timeout")
+		assertLockError(t, err, "acquire", "timeout", db.ErrLockTimeout)
+		if db.IsRetryable(err) {
+			t.Error("timeout is reported as retryable")
+		}
+	})
+
+	t.Run("cancel while waiting", func(t *testing.T) {
+		backend := newBackend(t)
+		a := newTestLocker(t, backend)
+		b := newTestLocker(t, backend)
+
+		mustAcquire(t, a, "cancel")
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(100*time.Millisecond, cancel)
+		assertLockError(t, b.Acquire(ctx, "cancel"), "acquire", "cancel", db.ErrLockKilled)
+	})
+
+	t.Run("cancelled before acquiring", func(t *testing.T) {
+		backend := newBackend(t)
+		a := newTestLocker(t, backend)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		assertLockError(t, a.Acquire(ctx, "cancelled"), "acquire", "cancelled", db.ErrLockKilled)
+		if held, _ := a.IsHeld(context.Background(), "cancelled"); held {
+			t.Fatal("lock acquired with a cancelled context")
+		}
+	})
+
+	t.Run("expired deadline still tries once", func(t *testing.T) {
+		backend := newBackend(t)
+		a := newTestLocker(t, backend)
+
+		ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+		defer cancel()
+		if err := a.Acquire(ctx, "expired"); err != nil {
+			t.Fatalf("Acquire of a free lock with an expired deadline = %v, want nil", err)
+		}
+	})
+
+	t.Run("waiter acquires after release", func(t *testing.T) {
+		ctx := context.Background()
+		backend := newBackend(t)
+		a := newTestLocker(t, backend)
+		b := newTestLocker(t, backend)
+
+		mustAcquire(t, a, "handoff")
+		done := make(chan error, 1)
+		go func() {
+			waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			defer cancel()
+			done <- b.Acquire(waitCtx, "handoff")
+		}()
+
+		time.Sleep(100 * time.Millisecond)
+		if err := a.Release(ctx, "handoff"); err != nil {
+			t.Fatalf("Release: %v", err)
+		}
+		if err := <-done; err != nil {
+			t.Fatalf("waiting Acquire = %v, want nil", err)
+		}
+		if held, _ := b.IsHeld(ctx, "handoff"); !held {
+			t.Fatal("waiter does not hold the lock")
+		}
+	})
+
+	t.Run("close releases all holds", func(t *testing.T) {
+		ctx := context.Background()
+		backend := newBackend(t)
+		a := newTestLocker(t, backend)
+		b := newTestLocker(t, backend)
+
+		mustAcquire(t, a, "close-x")
+		mustAcquire(t, a, "close-x")
+		mustAcquire(t, a, "close-y")
+		if err := a.Close(ctx); err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+		for _, name := range []string{"close-x", "close-y"} {
+			if acquired, err := b.TryAcquire(ctx, name); err != nil || !acquired {
+				t.Fatalf("TryAcquire(%s) after Close = %t, %v; want true, nil", name, acquired, err)
+			}
+		}
+		assertLockError(t, a.Acquire(ctx, "close-x"), "acquire", "close-x", db.ErrLockNotHeld)
+	})
+}
+
+// newTestLocker はテストの終了時に閉じるセッションを作成する
+func newTestLocker(t *testing.T, backend Backend) Locker {
+	t.Helper()
+	l, err := backend.NewLocker(context.Background())
+	if err != nil {
+		t.Fatalf("NewLocker: %v", err)
+	}
+	t.Cleanup(func() { l.Close(context.Background()) })
+	return l
+}
+
+// mustAcquire は待機なしでロックを取得する（取得できない場合はテストを失敗させる）
+func mustAcquire(t *testing.T, l Locker, name string) {
+	t.Helper()
+	acquired, err := l.TryAcquire(context.Background(), name)
+	if err != nil || !acquired {
+		t.Fatalf("TryAcquire(%s) = %t, %v; want true, nil", name, acquired, err)
+	}
+}
+
+// assertLockError はエラーが op と name を持つ *db.LockError で、kind に分類されていることを確認する
+func assertLockError(t *testing.T, err error, op string, name string, kind error) {
+	t.Helper()
+	var lockErr *db.LockError
+	if !errors.As(err, &lockErr) {
+		t.Fatalf("error = %v (%T), want *db.LockError", err, err)
+	}
+	if lockErr.Op != op || lockErr.LockName != name {
+		t.Errorf("LockError op/name = %s/%s, want %s/%s", lockErr.Op, lockErr.LockName, op, name)
+	}
+	if !errors.Is(err, kind) {
+		t.Errorf("error = %v, want %v", err, kind)
+	}
+}
@@ -0,0 +1,182 @@
+package locker
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// memoryBackend はプロセス内のロックのバックエンド
+type memoryBackend struct {
+	mu sync.Mutex
+	// locks はロック名ごとの保持状態（保持されていないロックは含まない）
+	locks map[string]*memoryLock
+	// nextID はセッションIDの採番に使う
+	nextID atomic.Int64
+}
+
+// memoryLock はひとつのロック名の保持状態
+type memoryLock struct {
+	owner *memoryLocker
+	// count は所有するセッションが取得した回数
+	count int
+	// freed はロックが解放された時点で閉じられる（待っているセッションを起こす）
+	freed chan struct{}
+}
+
+// memoryLocker はプロセス内のロックのセッション
+type memoryLocker struct {
+	backend *memoryBackend
+	id      string
+	// holds はロック名ごとの取得回数（Close で解放するため）
+	holds  map[string]int
+	lost   chan struct{}
+	closed bool
+}
+
+// NewMemoryBackend はプロセス内のロックのバックエンドを作成する
+// ロックは同じバックエンドから作成したセッションの間でのみ共有される
+func NewMemoryBackend() Backend {
+	return &memoryBackend{locks: make(map[string]*memoryLock)}
+}
+
+// Name はバックエンドの種類を返す
+func (b *memoryBackend) Name() string {
+	return BackendMemory
+}
+
+// NewLocker は新しいセッションを作成する
+func (b *memoryBackend) NewLocker(ctx context.Context) (Locker, error) {
+	return &memoryLocker{
+		backend: b,
+		id:      strconv.FormatInt(b.nextID.Add(1), 10),
+		holds:   make(map[string]int),
+		lost:    make(chan struct{}),
+	}, nil
+}
+
+// SessionID はセッションの識別子を返す
+func (l *memoryLocker) SessionID() string {
+	return l.id
+}
+
+// Acquire はロックを取得するまで待つ
+func (l *memoryLocker) Acquire(ctx context.Context, name string) error {
+	return l.acquire(ctx, name, true)
+}
+
+// TryAcquire は待機せずにロックの取得を試みる
+func (l *memoryLocker) TryAcquire(ctx context.Context, name string) (bool, error) {
+	err := l.acquire(ctx, name, false)
+	if errors.Is(err, errBusy) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// errBusy は待機なしの取得で、他のセッションがロックを保持していたことを表す
+var errBusy = errors.New("lock is held by another session")
+
+// acquire はロックを取得する
+// blocking: 他のセッションが保持している場合に、解放されるまで待つか（待たない場合は errBusy を返す）
+func (l *memoryLocker) acquire(ctx context.Context, name string, blocking bool) error {
+	if l.closed {
+		return closedError("acquire", name)
+	}
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return waitError(ctx, name)
+	}
+
+	for {
+		freed, ok := l.tryLock(name)
+		if ok {
+			l.holds[name]++
+			return nil
+		}
+		if !blocking {
+			return errBusy
+		}
+		// 期限切れのコンテキストでも一度は取得を試みる（GET_LOCK(name, 0) と同じ）
+		if ctx.Err() != nil {
+			return waitError(ctx, name)
+		}
+
+		select {
+		case <-freed:
+		case <-ctx.Done():
+			return waitError(ctx, name)
+		}
+	}
+}
+
+// tryLock はロックが空いているか、このセッションが保持している場合に取得回数を増やす
+// 他のセッションが保持している場合は、解放された時点で閉じられるチャネルを返す
+func (l *memoryLocker) tryLock(name string) (<-chan struct{}, bool) {
+	b := l.backend
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	lock, ok := b.locks[name]
+	if !ok {
+		b.locks[name] = &memoryLock{owner: l, count: 1, freed: make(chan struct{})}
+		return nil, true
+	}
+	if lock.owner == l {
+		lock.count++
+		return nil, true
+	}
+	return lock.freed, false
+}
+
+// Release はロックを1回だけ解放する
+func (l *memoryLocker) Release(ctx context.Context, name string) error {
+	b := l.backend
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	lock, ok := b.locks[name]
+	if l.closed || !ok || lock.owner != l {
+		return notHeldError(name)
+	}
+	lock.count--
+	l.holds[name]--
+	if lock.count == 0 {
+		delete(b.locks, name)
+		delete(l.holds, name)
+		close(lock.freed)
+	}
+	return nil
+}
+
+// IsHeld はこのセッションがロックを保持しているかを返す
+func (l *memoryLocker) IsHeld(ctx context.Context, name string) (bool, error) {
+	b := l.backend
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	lock, ok := b.locks[name]
+	return ok && lock.owner == l, nil
+}
+
+// Lost はロックを失った時点で閉じられるチャネルを返す（プロセス内のロックは失わないため閉じられない）
+func (l *memoryLocker) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// Close は保持しているすべてのロックを、取得した回数だけ解放する
+func (l *memoryLocker) Close(ctx context.Context) error {
+	if l.closed {
+		return nil
+	}
+	for name, count := range l.holds {
+		for range count {
+			if err := l.Release(ctx, name); err != nil {
+				return err
+			}
+		}
+	}
+	l.closed = true
+	return nil
+}
@@ -0,0 +1,22 @@
+package locker
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMemoryBackend(t *testing.T) {
+	testBackendSemantics(t, func(t *testing.T) Backend {
+		return NewMemoryBackend()
+	})
+}
+
+func TestMemoryBackendsAreIsolated(t *testing.T) {
+	a := newTestLocker(t, NewMemoryBackend())
+	b := newTestLocker(t, NewMemoryBackend())
+
+	mustAcquire(t, a, "isolated")
+	if acquired, err := b.TryAcquire(context.Background(), "isolated"); err != nil || !acquired {
+		t.Fatalf("TryAcquire on another backend = %t, %v; want true, nil", acquired, err)
+	}
+}
@@ -0,0 +1,160 @@
+package locker
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"github.com/example/named-lock/internal/db"
+)
+
+// mysqlBackend はMySQLの名前付きロックのバックエンド
+type mysqlBackend struct {
+	db *db.DB
+}
+
+// mysqlLocker はMySQLの名前付きロックのセッション
+// 最初にロックを取得した接続を Close まで保持し、以降のロックは同じ接続で取得する
+type mysqlLocker struct {
+	db *db.DB
+	// lock は最初にロックを取得した接続のハンドル（取得するまでは nil）
+	lock *db.Lock
+	// lost はロックを失った時点で閉じられる
+	lost     chan struct{}
+	lostOnce sync.Once
+	// done は Close で閉じられ、ロックの喪失の監視を終了する
+	done   chan struct{}
+	closed bool
+}
+
+// NewMySQLBackend はMySQLの名前付きロックのバックエンドを作成する
+func NewMySQLBackend(database *db.DB) Backend {
+	return &mysqlBackend{db: database}
+}
+
+// Name はバックエンドの種類を返す
+func (b *mysqlBackend) Name() string {
+	return BackendMySQL
+}
+
+// NewLocker は新しいセッションを作成する
+// 接続は最初にロックを取得する時点で取得する
+func (b *mysqlBackend) NewLocker(ctx context.Context) (Locker, error) {
+	return &mysqlLocker{
+		db:   b.db,
+		lost: make(chan struct{}),
+		done: make(chan struct{}),
+	}, nil
+}
+
+// SessionID はロックを保持している接続のセッションIDを返す（最初にロックを取得するまでは空）
+func (l *mysqlLocker) SessionID() string {
+	if l.lock == nil {
+		return ""
+	}
+	return strconv.FormatInt(l.lock.ConnectionID(), 10)
+}
+
+// Acquire はロックを取得するまで待つ
+func (l *mysqlLocker) Acquire(ctx context.Context, name string) error {
+	if l.closed {
+		return closedError("acquire", name)
+	}
+	if l.lock == nil {
+		lock, err := l.db.AcquireLock(ctx, name)
+		if err != nil {
+			return err
+		}
+		l.watch(lock)
+		return nil
+	}
+
+	err := l.lock.Acquire(ctx, name)
+	l.checkClosed()
+	return err
+}
+
+// TryAcquire は待機せずにロックの取得を試みる
+func (l *mysqlLocker) TryAcquire(ctx context.Context, name string) (bool, error) {
+	if l.closed {
+		return false, closedError("acquire", name)
+	}
+	if l.lock == nil {
+		lock, acquired, err := l.db.TryLock(ctx, name)
+		if err != nil || !acquired {
+			return false, err
+		}
+		l.watch(lock)
+		return true, nil
+	}
+
+	acquired, err := l.lock.TryAcquire(ctx, name)
+	l.checkClosed()
+	return acquired, err
+}
+
+// Release はロックを1回だけ解放する
+// 取得回数が0になっても接続は保持したままにする（Close で接続をプールに戻す）
+func (l *mysqlLocker) Release(ctx context.Context, name string) error {
+	if l.closed || l.lock == nil {
+		return notHeldError(name)
+	}
+	err := l.lock.ReleaseOne(ctx, name)
+	l.checkClosed()
+	return err
+}
+
+// IsHeld はこのセッションの接続がロックを保持しているかを IS_USED_LOCK で確認する
+func (l *mysqlLocker) IsHeld(ctx context.Context, name string) (bool, error) {
+	if l.closed || l.lock == nil {
+		return false, nil
+	}
+	connID, used, err := l.db.IsUsedLock(ctx, name)
+	if err != nil {
+		return false, err
+	}
+	return used && connID == l.lock.ConnectionID(), nil
+}
+
+// Lost はセッションの接続が切断されるなどして、ロックを失った時点で閉じられるチャネルを返す
+func (l *mysqlLocker) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// Close は保持しているすべてのロックを解放し、接続をプールに戻す
+func (l *mysqlLocker) Close(ctx context.Context) error {
+	if l.closed {
+		return nil
+	}
+	l.closed = true
+	close(l.done)
+	if l.lock == nil {
+		return nil
+	}
+	return l.lock.Release(ctx)
+}
+
+// watch は最初にロックを取得した接続のハンドルを保持し、ロックの喪失の監視を開始する
+func (l *mysqlLocker) watch(lock *db.Lock) {
+	l.lock = lock
+	go func() {
+		select {
+		case <-lock.Lost():
+			l.markLost()
+		case <-l.done:
+		}
+	}()
+}
+
+// checkClosed はエラーで接続が破棄された場合に、ロックを失ったものとして通知する
+// 接続を破棄した場合、そのセッションが保持していたロックはすべて解放されている
+func (l *mysqlLocker) checkClosed() {
+	if l.lock.Closed() {
+		l.markLost()
+	}
+}
+
+// markLost は Lost のチャネルを閉じる
+func (l *mysqlLocker) markLost() {
+	l.lostOnce.Do(func() { close(l.lost) })
+}
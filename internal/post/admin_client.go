@@ -0,0 +1,117 @@
+package post
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// ロックを保持・待機しているセッションの構造体
+type LockSessionResponse struct {
+	SessionID string `json:"session_id"`
+	User      string `json:"user,omitempty"`
+	Host      string `json:"host,omitempty"`
+	Seconds   int64  `json:"seconds"`
+}
+
+// 名前付きロック一覧レスポンスの構造体
+type AdminLocksResponse struct {
+	Source  string `json:"source"`
+	Warning string `json:"warning,omitempty"`
+	Locks   []struct {
+		LockName string                `json:"lock_name"`
+		LockKey  string                `json:"lock_key"`
+		Holder   *LockSessionResponse  `json:"holder,omitempty"`
+		Waiters  []LockSessionResponse `json:"waiters"`
+	} `json:"locks"`
+}
+
+// 名前付きロックの強制解放リクエストの構造体
+type ForceReleaseRequest struct {
+	Reason    string `json:"reason"`
+	DryRun    bool   `json:"dry_run"`
+	KillQuery bool   `json:"kill_query"`
+}
+
+// 名前付きロックの強制解放レスポンスの構造体
+type ForceReleaseResponse struct {
+	Success  bool                `json:"success"`
+	LockName string              `json:"lock_name"`
+	ActionID int64               `json:"action_id"`
+	Action   string              `json:"action"`
+	Result   string              `json:"result"`
+	DryRun   bool                `json:"dry_run"`
+	Owner    LockSessionResponse `json:"owner"`
+}
+
+// 名前付きロックの保持者と待機者の一覧を取得（管理用API）
+func (c *Client) ListAdminLocks() (*AdminLocksResponse, error) {
+	var locksResp AdminLocksResponse
+	if err := c.getJSON("/api/admin/locks", &locksResp); err != nil {
+		return nil, err
+	}
+
+	return &locksResp, nil
+}
+
+// ロックを保持しているセッションを KILL して強制的に解放（管理用API）
+func (c *Client) ForceRelease(lockName string, req ForceReleaseRequest) (*ForceReleaseResponse, error) {
+	var releaseResp ForceReleaseResponse
+	if err := c.postJSON("/api/admin/locks/"+url.PathEscape(lockName)+"/release", req, &releaseResp); err != nil {
+		return nil, err
+	}
+
+	return &releaseResp, nil
+}
+
+// 定期実行ジョブの実行記録の構造体
+type JobRunResponse struct {
+	ID          int64  `json:"id"`
+	JobName     string `json:"job_name"`
+	ScheduledAt string `json:"scheduled_at"`
+	StartedAt   string `json:"started_at"`
+	FinishedAt  string `json:"finished_at,omitempty"`
+	DurationMs  int64  `json:"duration_ms"`
+	Outcome     string `json:"outcome"`
+	SessionID   string `json:"session_id"`
+	Instance    string `json:"instance"`
+	Message     string `json:"message,omitempty"`
+}
+
+// 定期実行ジョブ一覧レスポンスの構造体
+type JobsResponse struct {
+	Jobs []struct {
+		Name            string          `json:"name"`
+		Spec            string          `json:"spec"`
+		TimeoutSeconds  int             `json:"timeout_seconds"`
+		NextRunAt       string          `json:"next_run_at,omitempty"`
+		HolderSessionID string          `json:"holder_session_id,omitempty"`
+		LastRun         *JobRunResponse `json:"last_run,omitempty"`
+	} `json:"jobs"`
+}
+
+// 定期実行ジョブの実行記録レスポンスの構造体
+type JobRunsResponse struct {
+	JobName string           `json:"job_name"`
+	Runs    []JobRunResponse `json:"runs"`
+}
+
+// 定期実行ジョブの一覧を取得（管理用API）
+func (c *Client) ListJobs() (*JobsResponse, error) {
+	var jobsResp JobsResponse
+	if err := c.getJSON("/api/admin/jobs", &jobsResp); err != nil {
+		return nil, err
+	}
+
+	return &jobsResp, nil
+}
+
+// 定期実行ジョブの実行記録を新しい順に取得（管理用API）
+func (c *Client) ListJobRuns(name string, limit int) (*JobRunsResponse, error) {
+	var runsResp JobRunsResponse
+	path := fmt.Sprintf("/api/admin/jobs/%s/runs?limit=%d", url.PathEscape(name), limit)
+	if err := c.getJSON(path, &runsResp); err != nil {
+		return nil, err
+	}
+
+	return &runsResp, nil
+}
@@ -1,29 +1,83 @@
 package post
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"strconv"
 	"sync"
 	"time"
 )
 
+// APIサーバーのURL
+const baseURL = "http://localhost:8080"
+
 // APIレスポンスの構造体
 type SessionResponse struct {
 	SessionID string `json:"session_id"`
 }
 
 type LockResponse struct {
+	Success    bool   `json:"success"`
+	SessionID  string `json:"session_id,omitempty"`
+	LeaseID    string `json:"lease_id,omitempty"`
+	FenceToken int64  `json:"fence_token,omitempty"`
+	ExpiresAt  string `json:"expires_at,omitempty"`
+	Message    string `json:"message,omitempty"`
+	// 順番待ちの統計（公平モードで取得した場合のみ）
+	Queue *QueueStatsResponse `json:"queue,omitempty"`
+}
+
+// 順番待ち（公平モード）の統計の構造体
+type QueueStatsResponse struct {
+	Ticket    int64 `json:"ticket"`
+	Position  int   `json:"position"`
+	Abandoned int   `json:"abandoned"`
+	WaitedMs  int64 `json:"waited_ms"`
+}
+
+// エラーレスポンスの構造体（すべてのルートで共通）
+type ErrorResponse struct {
 	Success   bool   `json:"success"`
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	LockName  string `json:"lock_name,omitempty"`
 	SessionID string `json:"session_id,omitempty"`
-	Message   string `json:"message,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+	Retryable bool   `json:"retryable,omitempty"`
+	// 在庫不足の場合の、その時点の在庫数（code が insufficient_stock の場合のみ）
+	CurrentQuantity *int `json:"current_quantity,omitempty"`
+	Fields          []struct {
+		Field   string `json:"field"`
+		Message string `json:"message"`
+	} `json:"fields,omitempty"`
+	// 受け付けられなかった注文明細（code が checkout_rejected の場合のみ）
+	Lines []struct {
+		Index       int    `json:"index"`
+		ProductCode string `json:"product_code"`
+		Quantity    int    `json:"quantity"`
+		Available   int    `json:"available"`
+		Reason      string `json:"reason"`
+	} `json:"lines,omitempty"`
+}
+
+// サーバーが返したエラー（2xx以外のステータス）
+type APIError struct {
+	StatusCode int
+	ErrorResponse
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("status %d, code %s: %s (request_id: %s)", e.StatusCode, e.Code, e.Message, e.RequestID)
 }
 
 type LockStatusResponse struct {
 	LockName                string `json:"lock_name"`
+	LockKey                 string `json:"lock_key"`
 	IsLocked                bool   `json:"is_locked"`
 	OwnerSessionID          string `json:"owner_session_id,omitempty"`
 	CurrentSessionID        string `json:"current_session_id"`
@@ -34,6 +88,8 @@ type LockStatusResponse struct {
 type Client struct {
 	ID     int
 	Client *http.Client
+	// 管理用APIの認証トークン（空の場合は Authorization ヘッダを付与しない）
+	AdminToken string
 }
 
 // 新しいクライアントを作成
@@ -44,42 +100,80 @@ func NewClient(id int) *Client {
 	}
 }
 
-// セッションIDを取得
-func (c *Client) GetSessionID() (string, error) {
-	resp, err := c.Client.Get("http://localhost:8080/api/session")
+// リクエストを送信し、レスポンスボディをJSONとしてoutにデコードする
+// 2xx以外のステータスの場合は、エラーレスポンスを *APIError として返す
+func (c *Client) doJSON(req *http.Request, out interface{}) error {
+	if c.AdminToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.AdminToken)
+	}
+	resp, err := c.Client.Do(req)
 	if err != nil {
-		return "", err
+		return err
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	var sessionResp SessionResponse
-	if err := json.Unmarshal(body, &sessionResp); err != nil {
-		return "", err
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		if err := json.Unmarshal(body, &apiErr.ErrorResponse); err != nil {
+			apiErr.Message = string(body)
+		}
+		return apiErr
 	}
 
-	return sessionResp.SessionID, nil
+	return json.Unmarshal(body, out)
 }
 
-// ロックの状態を取得
-func (c *Client) GetLockStatus(lockName string) (*LockStatusResponse, error) {
-	resp, err := c.Client.Get("http://localhost:8080/api/locks/" + lockName)
+// GETリクエストを送信する
+func (c *Client) getJSON(path string, out interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, baseURL+path, nil)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer resp.Body.Close()
+	return c.doJSON(req, out)
+}
 
-	body, err := io.ReadAll(resp.Body)
+// JSONボディ付きのPOSTリクエストを送信する
+func (c *Client) postJSON(path string, in interface{}, out interface{}) error {
+	reqBody, err := json.Marshal(in)
 	if err != nil {
-		return nil, err
+		return err
 	}
+	req, err := http.NewRequest(http.MethodPost, baseURL+path, bytes.NewBuffer(reqBody))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return c.doJSON(req, out)
+}
 
+// DELETEリクエストを送信する
+func (c *Client) deleteJSON(path string, out interface{}) error {
+	req, err := http.NewRequest(http.MethodDelete, baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	return c.doJSON(req, out)
+}
+
+// セッションIDを取得
+func (c *Client) GetSessionID() (string, error) {
+	var sessionResp SessionResponse
+	if err := c.getJSON("/api/session", &sessionResp); err != nil {
+		return "", err
+	}
+
+	return sessionResp.SessionID, nil
+}
+
+// ロックの状態を取得
+func (c *Client) GetLockStatus(lockName string) (*LockStatusResponse, error) {
 	var statusResp LockStatusResponse
-	if err := json.Unmarshal(body, &statusResp); err != nil {
+	if err := c.getJSON("/api/locks/"+url.PathEscape(lockName), &statusResp); err != nil {
 		return nil, err
 	}
 
@@ -0,0 +1,120 @@
+package post
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CheckoutLine は注文明細の構造体
+type CheckoutLine struct {
+	ProductCode string `json:"product_code"`
+	Quantity    int    `json:"quantity"`
+}
+
+// CheckoutRequest は複数商品の注文リクエストの構造体
+type CheckoutRequest struct {
+	Lines   []CheckoutLine `json:"lines"`
+	Timeout int            `json:"timeout"`
+}
+
+// CheckoutResponse は複数商品の注文レスポンスの構造体
+type CheckoutResponse struct {
+	Success bool `json:"success"`
+	Orders  []struct {
+		OrderID     string `json:"order_id"`
+		ProductCode string `json:"product_code"`
+		Quantity    int    `json:"quantity"`
+		Remaining   int    `json:"remaining"`
+	} `json:"orders"`
+	Message string `json:"message,omitempty"`
+}
+
+// 複数商品をまとめて注文する
+// 在庫が足りない明細がある場合は、明細ごとの理由を持つ *APIError（code: checkout_rejected）を返す
+func (c *Client) Checkout(lines []CheckoutLine, timeout int) (*CheckoutResponse, error) {
+	reqBody := CheckoutRequest{
+		Lines:   lines,
+		Timeout: timeout,
+	}
+
+	var checkoutResp CheckoutResponse
+	if err := c.postJSON("/api/checkout", reqBody, &checkoutResp); err != nil {
+		return nil, err
+	}
+
+	return &checkoutResp, nil
+}
+
+// checkoutProductCodes はテストで使用する商品コードを返す
+func checkoutProductCodes(prefix string) []string {
+	return []string{prefix + "_a", prefix + "_b"}
+}
+
+// 複数商品の注文テスト（クライアントごとに明細の順序を変えて注文する）
+func RunCheckout(c *Client, prefix string, args ...interface{}) {
+	// 実行開始時間を記録
+	startTime := time.Now()
+
+	// 奇数のクライアントは逆順に指定する（サーバーが順序を揃えるためデッドロックしない）
+	codes := checkoutProductCodes(prefix)
+	if c.ID%2 == 1 {
+		codes[0], codes[1] = codes[1], codes[0]
+	}
+	lines := []CheckoutLine{
+		{ProductCode: codes[0], Quantity: 1},
+		{ProductCode: codes[1], Quantity: 1},
+	}
+
+	fmt.Printf("Client %d [%.1fs]: Checking out: %+v\n", c.ID, time.Since(startTime).Seconds(), lines)
+	checkoutResp, err := c.Checkout(lines, -1)
+	if err != nil {
+		fmt.Printf("Client %d [%.1fs]: Checkout failed: %v\n", c.ID, time.Since(startTime).Seconds(), err)
+		return
+	}
+	fmt.Printf("Client %d [%.1fs]: Checkout result: %+v\n", c.ID, time.Since(startTime).Seconds(), checkoutResp)
+}
+
+// 複数商品の注文テストを実行する関数
+// 並列数と同じ在庫を用意して並列に注文した後、在庫を超える注文が明細ごとの理由付きで拒否されることを確認する
+func RunCheckoutTest(startID int, parallelCount int) bool {
+	prefix := uuid.New().String()
+	codes := checkoutProductCodes(prefix)
+	setup := NewClient(startID)
+
+	// 在庫を用意
+	for _, code := range codes {
+		if _, err := setup.AcquireProductReleaseLock(code, parallelCount, -1); err != nil {
+			fmt.Printf("Failed to add product %s: %v\n", code, err)
+			return false
+		}
+	}
+	fmt.Printf("Added products %v with quantity %d\n", codes, parallelCount)
+
+	// 並列に注文
+	RunParallel(startID, parallelCount, prefix, RunCheckout)
+
+	// 在庫を使い切っているため、追加の注文は拒否される
+	_, err := setup.Checkout([]CheckoutLine{
+		{ProductCode: codes[0], Quantity: 1},
+		{ProductCode: codes[1], Quantity: 1},
+	}, -1)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != "checkout_rejected" {
+		fmt.Printf("Expected checkout to be rejected, got: %v\n", err)
+		return false
+	}
+	for _, line := range apiErr.Lines {
+		fmt.Printf("Rejected line %d: %s %s (requested: %d, available: %d)\n",
+			line.Index, line.ProductCode, line.Reason, line.Quantity, line.Available)
+	}
+	if len(apiErr.Lines) != len(codes) {
+		fmt.Printf("Expected %d rejected lines, got %d\n", len(codes), len(apiErr.Lines))
+		return false
+	}
+
+	fmt.Println("Checkout test passed: all products sold out without overselling")
+	return true
+}
@@ -0,0 +1,116 @@
+package post
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ProductResponse は商品情報レスポンスの構造体
+type ProductResponse struct {
+	ProductCode string `json:"product_code"`
+	Quantity    int    `json:"quantity"`
+}
+
+// decrementAttempts は各クライアントが在庫を減らす回数
+const decrementAttempts = 3
+
+// ロックを取得し、在庫を減らし、解放する
+// 在庫が足りない場合は *APIError（code: insufficient_stock）を返す
+func (c *Client) DecrementProduct(productCode string, quantity int, timeout int) (*ProductLockResponse, error) {
+	reqBody := ProductLockRequest{
+		ProductCode: productCode,
+		Quantity:    quantity,
+		Timeout:     timeout,
+		Mode:        "decrement",
+	}
+
+	var processResp ProductLockResponse
+	if err := c.postJSON("/api/locks/product", reqBody, &processResp); err != nil {
+		return nil, err
+	}
+
+	return &processResp, nil
+}
+
+// 商品の在庫数を取得する
+func (c *Client) GetProduct(productCode string) (*ProductResponse, error) {
+	var productResp ProductResponse
+	if err := c.getJSON("/api/products/"+url.PathEscape(productCode), &productResp); err != nil {
+		return nil, err
+	}
+
+	return &productResp, nil
+}
+
+// 在庫を減らすテスト（在庫が足りない場合に拒否されることを確認する）
+// args: 成功回数を数える *int64、想定外の結果を数える *int64
+func RunDecrement(c *Client, productCode string, args ...interface{}) {
+	// 実行開始時間を記録
+	startTime := time.Now()
+
+	succeeded := args[0].(*int64)
+	unexpected := args[1].(*int64)
+
+	for i := 0; i < decrementAttempts; i++ {
+		resp, err := c.DecrementProduct(productCode, 1, -1)
+		if err == nil {
+			atomic.AddInt64(succeeded, 1)
+			fmt.Printf("Client %d [%.1fs]: Decrement result: %+v\n", c.ID, time.Since(startTime).Seconds(), resp)
+			continue
+		}
+
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.Code == "insufficient_stock" && apiErr.CurrentQuantity != nil && *apiErr.CurrentQuantity >= 0 {
+			fmt.Printf("Client %d [%.1fs]: Rejected as expected (current quantity: %d)\n", c.ID, time.Since(startTime).Seconds(), *apiErr.CurrentQuantity)
+			continue
+		}
+		atomic.AddInt64(unexpected, 1)
+		fmt.Printf("Client %d [%.1fs]: Decrement failed: %v\n", c.ID, time.Since(startTime).Seconds(), err)
+	}
+}
+
+// 在庫を減らすテストを実行する関数
+// 並列数と同じ在庫に対して、在庫を超える回数の減算を並列に行い、
+// 最終的な在庫数が0未満にならず、成功した回数だけ減っていることを確認する
+func RunDecrementTest(startID int, parallelCount int) bool {
+	productCode := uuid.New().String() // ランダムな商品コードを生成
+	setup := NewClient(startID)
+
+	// 在庫を用意
+	initial := parallelCount
+	if _, err := setup.AcquireProductReleaseLock(productCode, initial, -1); err != nil {
+		fmt.Printf("Failed to add product %s: %v\n", productCode, err)
+		return false
+	}
+	fmt.Printf("Added product %s with quantity %d\n", productCode, initial)
+
+	// 並列に在庫を減らす
+	var succeeded, unexpected int64
+	RunParallel(startID, parallelCount, productCode, RunDecrement, &succeeded, &unexpected)
+
+	// 最終的な在庫数を確認
+	product, err := setup.GetProduct(productCode)
+	if err != nil {
+		fmt.Printf("Failed to get product: %v\n", err)
+		return false
+	}
+	fmt.Printf("Final quantity: %d (initial: %d, succeeded: %d, unexpected errors: %d)\n",
+		product.Quantity, initial, succeeded, unexpected)
+
+	if product.Quantity < 0 {
+		fmt.Println("Decrement test failed: quantity became negative")
+		return false
+	}
+	if product.Quantity != initial-int(succeeded) || unexpected > 0 {
+		fmt.Println("Decrement test failed: quantity does not match the successful decrements")
+		return false
+	}
+
+	fmt.Println("Decrement test passed: quantity never became negative")
+	return true
+}
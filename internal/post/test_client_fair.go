@@ -0,0 +1,102 @@
+package post
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// fairHoldDuration は公平モードのテストでロックを保持する時間（秒）
+const fairHoldDuration = 1
+
+// 順番待ち（公平モード）でロックを取得し、保持し、解放する
+func (c *Client) AcquireFairHoldReleaseLock(lockName string, timeout int, holdDuration int) (*LockResponse, error) {
+	reqBody := map[string]interface{}{
+		"lock_name":     lockName,
+		"timeout":       timeout,
+		"hold_duration": holdDuration,
+		"fair":          true,
+	}
+
+	var lockResp LockResponse
+	if err := c.postJSON("/api/locks/hold-and-release", reqBody, &lockResp); err != nil {
+		return nil, err
+	}
+
+	return &lockResp, nil
+}
+
+// fairResult はクライアントごとの順番待ちの統計と、レスポンスを受け取った時刻
+type fairResult struct {
+	clientID   int
+	queue      QueueStatsResponse
+	finishedAt time.Time
+}
+
+// fairResults はクライアントごとの結果を集める
+type fairResults struct {
+	mu      sync.Mutex
+	results []fairResult
+	failed  int
+}
+
+// 順番待ち（公平モード）でロックを取得・保持・解放するテスト
+// args: 結果を集める *fairResults
+func RunFairLock(c *Client, lockName string, args ...interface{}) {
+	// 実行開始時間を記録
+	startTime := time.Now()
+
+	results := args[0].(*fairResults)
+
+	lockResp, err := c.AcquireFairHoldReleaseLock(lockName, -1, fairHoldDuration)
+	results.mu.Lock()
+	defer results.mu.Unlock()
+	if err != nil || lockResp.Queue == nil {
+		results.failed++
+		fmt.Printf("Client %d [%.1fs]: Operation failed: %v\n", c.ID, time.Since(startTime).Seconds(), err)
+		return
+	}
+	results.results = append(results.results, fairResult{clientID: c.ID, queue: *lockResp.Queue, finishedAt: time.Now()})
+	fmt.Printf("Client %d [%.1fs]: Ticket %d (position: %d, abandoned: %d, waited: %dms)\n",
+		c.ID, time.Since(startTime).Seconds(), lockResp.Queue.Ticket, lockResp.Queue.Position, lockResp.Queue.Abandoned, lockResp.Queue.WaitedMs)
+}
+
+// 公平モードのテストを実行する関数
+// 並列に順番待ちでロックを取得し、順番待ちの番号の順にロックを取得したこと（追い越しがないこと）を確認する
+// 各クライアントは同じ時間だけ保持するため、レスポンスを受け取った順がロックを取得した順になる
+func RunFairLockTest(startID int, parallelCount int) bool {
+	lockName := "fair_lock_" + uuid.New().String()
+	results := &fairResults{}
+	RunParallel(startID, parallelCount, lockName, RunFairLock, results)
+
+	if results.failed > 0 {
+		fmt.Printf("Fair lock test failed: %d clients failed\n", results.failed)
+		return false
+	}
+
+	sort.Slice(results.results, func(i, j int) bool {
+		return results.results[i].finishedAt.Before(results.results[j].finishedAt)
+	})
+	overtaken := 0
+	var maxWait int64
+	for i, r := range results.results {
+		if i > 0 && r.queue.Ticket < results.results[i-1].queue.Ticket {
+			overtaken++
+			fmt.Printf("Client %d (ticket %d) was overtaken by ticket %d\n", r.clientID, r.queue.Ticket, results.results[i-1].queue.Ticket)
+		}
+		if r.queue.WaitedMs > maxWait {
+			maxWait = r.queue.WaitedMs
+		}
+	}
+	fmt.Printf("Served %d clients in ticket order, max wait: %dms\n", len(results.results)-overtaken, maxWait)
+	if overtaken > 0 {
+		fmt.Printf("Fair lock test failed: %d clients were overtaken\n", overtaken)
+		return false
+	}
+
+	fmt.Println("Fair lock test passed: clients acquired the lock in ticket order")
+	return true
+}
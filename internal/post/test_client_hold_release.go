@@ -1,37 +1,20 @@
 package post
 
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
-	"io"
 	"time"
 )
 
 // ロックを取得し、保持し、解放する
 func (c *Client) AcquireHoldReleaseLock(lockName string, timeout int, holdDuration int) (*LockResponse, error) {
-	reqBody, err := json.Marshal(map[string]interface{}{
+	reqBody := map[string]interface{}{
 		"lock_name":     lockName,
 		"timeout":       timeout,
 		"hold_duration": holdDuration,
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := c.Client.Post("http://localhost:8080/api/locks/hold-and-release", "application/json", bytes.NewBuffer(reqBody))
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
 	}
 
 	var lockResp LockResponse
-	if err := json.Unmarshal(body, &lockResp); err != nil {
+	if err := c.postJSON("/api/locks/hold-and-release", reqBody, &lockResp); err != nil {
 		return nil, err
 	}
 
@@ -0,0 +1,98 @@
+package post
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// ロックを取得する（解放するまでサーバー側で保持される）
+func (c *Client) AcquireLock(lockName string, timeout int) (*LockResponse, error) {
+	reqBody := map[string]interface{}{
+		"lock_name": lockName,
+		"timeout":   timeout,
+	}
+
+	var lockResp LockResponse
+	if err := c.postJSON("/api/locks", reqBody, &lockResp); err != nil {
+		return nil, err
+	}
+
+	return &lockResp, nil
+}
+
+// リースの期限を延長する
+func (c *Client) RenewLock(leaseID string, ttl int) (*LockResponse, error) {
+	reqBody := map[string]interface{}{
+		"ttl": ttl,
+	}
+
+	var lockResp LockResponse
+	if err := c.postJSON("/api/locks/"+url.PathEscape(leaseID)+"/renew", reqBody, &lockResp); err != nil {
+		return nil, err
+	}
+
+	return &lockResp, nil
+}
+
+// ロックを解放する
+func (c *Client) ReleaseLock(lockName string, leaseID string) (*LockResponse, error) {
+	path := "/api/locks/" + url.PathEscape(lockName)
+	if leaseID != "" {
+		path += "?lease_id=" + url.QueryEscape(leaseID)
+	}
+
+	var lockResp LockResponse
+	if err := c.deleteJSON(path, &lockResp); err != nil {
+		return nil, err
+	}
+
+	return &lockResp, nil
+}
+
+// 通常のロックテスト（取得・状態確認・解放を別々のリクエストで行う）
+func RunLockTest(c *Client, lockName string, args ...interface{}) {
+	// 実行開始時間を記録
+	startTime := time.Now()
+
+	// ロックを取得
+	fmt.Printf("Client %d [%.1fs]: Acquiring lock...\n", c.ID, time.Since(startTime).Seconds())
+	lockResp, err := c.AcquireLock(lockName, -1)
+	if err != nil {
+		fmt.Printf("Client %d [%.1fs]: Acquire failed: %v\n", c.ID, time.Since(startTime).Seconds(), err)
+		return
+	}
+	fmt.Printf("Client %d [%.1fs]: Acquire result: %+v\n", c.ID, time.Since(startTime).Seconds(), lockResp)
+
+	// ロックの状態を確認
+	status, err := c.GetLockStatus(lockName)
+	if err != nil {
+		fmt.Printf("Client %d [%.1fs]: Failed to get lock status: %v\n", c.ID, time.Since(startTime).Seconds(), err)
+	} else {
+		fmt.Printf("Client %d [%.1fs]: Lock status while held: %+v\n", c.ID, time.Since(startTime).Seconds(), status)
+	}
+
+	// リースを延長
+	renewResp, err := c.RenewLock(lockResp.LeaseID, 0)
+	if err != nil {
+		fmt.Printf("Client %d [%.1fs]: Renew failed: %v\n", c.ID, time.Since(startTime).Seconds(), err)
+	} else {
+		fmt.Printf("Client %d [%.1fs]: Renew result: %+v\n", c.ID, time.Since(startTime).Seconds(), renewResp)
+	}
+
+	time.Sleep(1 * time.Second)
+
+	// ロックを解放
+	lockResp, err = c.ReleaseLock(lockName, lockResp.LeaseID)
+	if err != nil {
+		fmt.Printf("Client %d [%.1fs]: Release failed: %v\n", c.ID, time.Since(startTime).Seconds(), err)
+		return
+	}
+	fmt.Printf("Client %d [%.1fs]: Release result: %+v\n", c.ID, time.Since(startTime).Seconds(), lockResp)
+}
+
+// 通常のロックテストを実行する関数
+func RunNormalLockTest(startID int, parallelCount int) {
+	lockName := "test_lock"
+	RunParallel(startID, parallelCount, lockName, RunLockTest)
+}
@@ -0,0 +1,105 @@
+package post
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// mysqlLockNameLimit はMySQLの名前付きロックの名前の最大文字数
+const mysqlLockNameLimit = 64
+
+// longNameResults はクライアントごとの複合ロック名テストの結果を集める
+type longNameResults struct {
+	mu     sync.Mutex
+	failed int
+}
+
+func (r *longNameResults) fail(c *Client, startTime time.Time, format string, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failed++
+	fmt.Printf("Client %d [%.1fs]: %s\n", c.ID, time.Since(startTime).Seconds(), fmt.Sprintf(format, args...))
+}
+
+// 複合ロック名（テナント/倉庫/商品）のロックを取得し、状態の確認と解放をパスで指定して行うテスト
+// args: 結果を集める *longNameResults
+func RunLongNameLock(c *Client, lockName string, args ...interface{}) {
+	// 実行開始時間を記録
+	startTime := time.Now()
+
+	results := args[0].(*longNameResults)
+
+	// ロックを取得
+	fmt.Printf("Client %d [%.1fs]: Acquiring lock: %s\n", c.ID, time.Since(startTime).Seconds(), lockName)
+	lockResp, err := c.AcquireLock(lockName, -1)
+	if err != nil {
+		results.fail(c, startTime, "Acquire failed: %v", err)
+		return
+	}
+
+	// パスで指定したロック名で状態を確認（MySQLのロック名はハッシュ化されている）
+	status, err := c.GetLockStatus(lockName)
+	switch {
+	case err != nil:
+		results.fail(c, startTime, "Failed to get lock status: %v", err)
+	case status.LockName != lockName:
+		results.fail(c, startTime, "Unexpected lock name in status: %s", status.LockName)
+	case !status.IsLocked || status.OwnerSessionID != lockResp.SessionID:
+		results.fail(c, startTime, "Unexpected status while held: %+v (session ID: %s)", status, lockResp.SessionID)
+	case utf8.RuneCountInString(status.LockKey) > mysqlLockNameLimit || status.LockKey == lockName:
+		results.fail(c, startTime, "Lock key was not shortened: %s", status.LockKey)
+	default:
+		fmt.Printf("Client %d [%.1fs]: Held %s as %s (session ID: %s)\n", c.ID, time.Since(startTime).Seconds(), status.LockName, status.LockKey, status.OwnerSessionID)
+	}
+
+	owner, err := c.GetLockOwner(lockName, lockResp.SessionID)
+	if err != nil {
+		results.fail(c, startTime, "Failed to get owner: %v", err)
+	} else if owner.IsOwnedBySession == nil || !*owner.IsOwnedBySession {
+		results.fail(c, startTime, "Unexpected owner while held: %+v", owner)
+	}
+
+	time.Sleep(500 * time.Millisecond)
+
+	// パスで指定したロック名で解放
+	if _, err := c.ReleaseLock(lockName, lockResp.LeaseID); err != nil {
+		results.fail(c, startTime, "Release failed: %v", err)
+		return
+	}
+	fmt.Printf("Client %d [%.1fs]: Released lock\n", c.ID, time.Since(startTime).Seconds())
+}
+
+// 複合ロック名のテストを実行する関数
+// '/' を含み、MySQLの上限（64文字）を超えるロック名で、取得・状態の確認・解放がすべて成功することを確認する
+func RunLongNameTest(startID int, parallelCount int) bool {
+	id := uuid.New().String()
+	lockName := fmt.Sprintf("tenant_%s/warehouse_tokyo_east/product_%s", id, strings.Repeat("x", 16))
+	fmt.Printf("Lock name: %s (%d characters)\n", lockName, utf8.RuneCountInString(lockName))
+
+	results := &longNameResults{}
+	RunParallel(startID, parallelCount, lockName, RunLongNameLock, results)
+
+	if results.failed > 0 {
+		fmt.Printf("Long name test failed: %d checks failed\n", results.failed)
+		return false
+	}
+
+	// すべて解放されていることを確認
+	free, err := NewClient(startID).IsLockFree(lockName)
+	if err != nil {
+		fmt.Printf("Failed to check free: %v\n", err)
+		return false
+	}
+	if !free.IsFree {
+		fmt.Println("Long name test failed: lock is still held")
+		return false
+	}
+
+	fmt.Println("Long name test passed: acquire, status and release succeeded with a composite name")
+	return true
+}
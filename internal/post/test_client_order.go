@@ -1,10 +1,7 @@
 package post
 
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
-	"io"
 	"time"
 
 	"github.com/google/uuid"
@@ -25,27 +22,13 @@ type OrderLockResponse struct {
 
 // AcquireOrderReleaseLock はロックを取得し、注文処理し、解放する
 func (c *Client) AcquireOrderReleaseLock(productCode string, timeout int) (*OrderLockResponse, error) {
-	reqBody, err := json.Marshal(OrderLockRequest{
+	reqBody := OrderLockRequest{
 		ProductCode: productCode,
 		Timeout:     timeout,
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := c.Client.Post("http://localhost:8080/api/locks/order", "application/json", bytes.NewBuffer(reqBody))
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
 	}
 
 	var orderResp OrderLockResponse
-	if err := json.Unmarshal(body, &orderResp); err != nil {
+	if err := c.postJSON("/api/locks/order", reqBody, &orderResp); err != nil {
 		return nil, err
 	}
 
@@ -1,10 +1,7 @@
 package post
 
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
-	"io"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,6 +12,7 @@ type ProductLockRequest struct {
 	ProductCode string `json:"product_code"`
 	Quantity    int    `json:"quantity"`
 	Timeout     int    `json:"timeout"`
+	Mode        string `json:"mode,omitempty"`
 }
 
 // ProductLockResponse はプロセスロックレスポンスの構造体
@@ -27,28 +25,14 @@ type ProductLockResponse struct {
 
 // AcquireProductReleaseLock はロックを取得し、処理し、解放する
 func (c *Client) AcquireProductReleaseLock(productCode string, quantity int, timeout int) (*ProductLockResponse, error) {
-	reqBody, err := json.Marshal(ProductLockRequest{
+	reqBody := ProductLockRequest{
 		ProductCode: productCode,
 		Quantity:    quantity,
 		Timeout:     timeout,
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := c.Client.Post("http://localhost:8080/api/locks/product", "application/json", bytes.NewBuffer(reqBody))
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
 	}
 
 	var processResp ProductLockResponse
-	if err := json.Unmarshal(body, &processResp); err != nil {
+	if err := c.postJSON("/api/locks/product", reqBody, &processResp); err != nil {
 		return nil, err
 	}
 
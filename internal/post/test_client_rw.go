@@ -0,0 +1,129 @@
+package post
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// rwHoldDuration は読み取りロックを保持したまま待機する時間（秒）
+const rwHoldDuration = 2
+
+// OrdersResponse は注文一覧レスポンスの構造体
+type OrdersResponse struct {
+	Success     bool   `json:"success"`
+	ProductCode string `json:"product_code"`
+	SessionID   string `json:"session_id"`
+	Orders      []struct {
+		OrderID     string `json:"order_id"`
+		ProductCode string `json:"product_code"`
+		Quantity    int    `json:"quantity"`
+	} `json:"orders"`
+}
+
+// 読み取りロックを取得して注文の一覧を取得する（holdDuration 秒だけ読み取りロックを保持する）
+func (c *Client) ListOrders(productCode string, timeout int, holdDuration int) (*OrdersResponse, error) {
+	query := url.Values{}
+	query.Set("timeout", strconv.Itoa(timeout))
+	query.Set("hold_duration", strconv.Itoa(holdDuration))
+
+	var ordersResp OrdersResponse
+	if err := c.getJSON("/api/orders/"+url.PathEscape(productCode)+"?"+query.Encode(), &ordersResp); err != nil {
+		return nil, err
+	}
+
+	return &ordersResp, nil
+}
+
+// 注文の一覧を読み取りロックで取得するテスト
+// args: 失敗した回数を数える *int64
+func RunReadOrders(c *Client, productCode string, args ...interface{}) {
+	// 実行開始時間を記録
+	startTime := time.Now()
+
+	failed := args[0].(*int64)
+
+	fmt.Printf("Client %d [%.1fs]: Listing orders with read lock: %s\n", c.ID, time.Since(startTime).Seconds(), productCode)
+	ordersResp, err := c.ListOrders(productCode, -1, rwHoldDuration)
+	if err != nil {
+		atomic.AddInt64(failed, 1)
+		fmt.Printf("Client %d [%.1fs]: List failed: %v\n", c.ID, time.Since(startTime).Seconds(), err)
+		return
+	}
+	fmt.Printf("Client %d [%.1fs]: Listed %d orders (session ID: %s)\n",
+		c.ID, time.Since(startTime).Seconds(), len(ordersResp.Orders), ordersResp.SessionID)
+}
+
+// 読み取り・書き込みロックのテストを実行する関数
+// 読み取りが同時に実行されること、読み取りロックの保持中は注文の挿入（書き込みロック）が待たされることを確認する
+func RunReadWriteTest(startID int, parallelCount int) bool {
+	productCode := uuid.New().String() // ランダムな商品コードを生成
+	setup := NewClient(startID)
+	hold := time.Duration(rwHoldDuration) * time.Second
+
+	// 注文を用意
+	if _, err := setup.AcquireOrderReleaseLock(productCode, -1); err != nil {
+		fmt.Printf("Failed to insert order %s: %v\n", productCode, err)
+		return false
+	}
+
+	// 1. 並列に読み取る（直列に実行された場合は並列数 × 保持時間かかる）
+	var failed int64
+	startTime := time.Now()
+	RunParallel(startID, parallelCount, productCode, RunReadOrders, &failed)
+	elapsed := time.Since(startTime)
+	fmt.Printf("Parallel reads finished in %.1fs (serialized: %.1fs)\n", elapsed.Seconds(), (time.Duration(parallelCount) * hold).Seconds())
+	if failed > 0 {
+		fmt.Printf("Read/write test failed: %d reads failed\n", failed)
+		return false
+	}
+	if parallelCount > 1 && elapsed >= time.Duration(parallelCount)*hold {
+		fmt.Println("Read/write test failed: reads did not run concurrently")
+		return false
+	}
+
+	// 2. 読み取りロックの保持中に注文を挿入する（読み取りが終わるまで待たされる）
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		RunParallel(startID, parallelCount, productCode, RunReadOrders, &failed)
+	}()
+	time.Sleep(hold / 4)
+
+	writeStart := time.Now()
+	if _, err := setup.AcquireOrderReleaseLock(productCode, -1); err != nil {
+		fmt.Printf("Failed to insert order %s: %v\n", productCode, err)
+		return false
+	}
+	writeElapsed := time.Since(writeStart)
+	wg.Wait()
+	fmt.Printf("Write finished in %.1fs while reads were holding the lock\n", writeElapsed.Seconds())
+	if failed > 0 {
+		fmt.Printf("Read/write test failed: %d reads failed\n", failed)
+		return false
+	}
+	if writeElapsed < hold/2 {
+		fmt.Println("Read/write test failed: write did not wait for the reads")
+		return false
+	}
+
+	// 3. 挿入した注文が読み取れることを確認
+	ordersResp, err := setup.ListOrders(productCode, -1, 0)
+	if err != nil {
+		fmt.Printf("Failed to list orders: %v\n", err)
+		return false
+	}
+	if len(ordersResp.Orders) != 2 {
+		fmt.Printf("Read/write test failed: expected 2 orders, got %d\n", len(ordersResp.Orders))
+		return false
+	}
+
+	fmt.Println("Read/write test passed: reads ran concurrently and the write waited for them")
+	return true
+}
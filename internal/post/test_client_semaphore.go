@@ -0,0 +1,186 @@
+package post
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// semaphoreHoldDuration はセマフォの許可を保持する時間（秒）
+const semaphoreHoldDuration = 1
+
+// SemaphoreRequest はセマフォの許可の取得・保持・解放リクエストの構造体
+type SemaphoreRequest struct {
+	Name         string `json:"name"`
+	Permits      int    `json:"permits"`
+	Timeout      int    `json:"timeout"`
+	HoldDuration int    `json:"hold_duration"`
+	Try          bool   `json:"try"`
+}
+
+// SemaphoreResponse はセマフォの許可の取得・保持・解放レスポンスの構造体
+type SemaphoreResponse struct {
+	Success    bool   `json:"success"`
+	Name       string `json:"name"`
+	Slot       string `json:"slot"`
+	SessionID  string `json:"session_id"`
+	Holders    int    `json:"holders"`
+	Permits    int    `json:"permits"`
+	AcquiredAt string `json:"acquired_at"`
+	ReleasedAt string `json:"released_at"`
+}
+
+// SemaphoreStatusResponse はセマフォの状態レスポンスの構造体
+type SemaphoreStatusResponse struct {
+	Name    string   `json:"name"`
+	Permits int      `json:"permits"`
+	Holders []string `json:"holders"`
+	InUse   int      `json:"in_use"`
+}
+
+// セマフォの許可を得て、指定された時間だけ保持してから解放する
+// try の場合にすべてのスロットが使用中であれば *APIError（code: lock_busy）を返す
+func (c *Client) AcquireHoldReleaseSemaphore(name string, permits int, timeout int, holdDuration int, try bool) (*SemaphoreResponse, error) {
+	reqBody := SemaphoreRequest{
+		Name:         name,
+		Permits:      permits,
+		Timeout:      timeout,
+		HoldDuration: holdDuration,
+		Try:          try,
+	}
+
+	var semaphoreResp SemaphoreResponse
+	if err := c.postJSON("/api/semaphores/hold-and-release", reqBody, &semaphoreResp); err != nil {
+		return nil, err
+	}
+
+	return &semaphoreResp, nil
+}
+
+// セマフォのスロットごとの保持者を取得する
+func (c *Client) GetSemaphoreStatus(name string, permits int) (*SemaphoreStatusResponse, error) {
+	var statusResp SemaphoreStatusResponse
+	path := fmt.Sprintf("/api/semaphores/%s?permits=%d", url.PathEscape(name), permits)
+	if err := c.getJSON(path, &statusResp); err != nil {
+		return nil, err
+	}
+
+	return &statusResp, nil
+}
+
+// semaphoreResults はクライアントごとの許可の保持結果を集める
+type semaphoreResults struct {
+	mu        sync.Mutex
+	responses []*SemaphoreResponse
+	failed    int
+}
+
+// セマフォの許可を得て保持するテスト
+// args: 許可の数（int）、結果を集める *semaphoreResults
+func RunSemaphore(c *Client, name string, args ...interface{}) {
+	// 実行開始時間を記録
+	startTime := time.Now()
+
+	permits := args[0].(int)
+	results := args[1].(*semaphoreResults)
+
+	fmt.Printf("Client %d [%.1fs]: Acquiring semaphore: %s (permits: %d)\n", c.ID, time.Since(startTime).Seconds(), name, permits)
+	semaphoreResp, err := c.AcquireHoldReleaseSemaphore(name, permits, -1, semaphoreHoldDuration, false)
+	results.mu.Lock()
+	defer results.mu.Unlock()
+	if err != nil {
+		results.failed++
+		fmt.Printf("Client %d [%.1fs]: Semaphore failed: %v\n", c.ID, time.Since(startTime).Seconds(), err)
+		return
+	}
+	results.responses = append(results.responses, semaphoreResp)
+	fmt.Printf("Client %d [%.1fs]: Held %s (session ID: %s, holders: %d/%d)\n",
+		c.ID, time.Since(startTime).Seconds(), semaphoreResp.Slot, semaphoreResp.SessionID, semaphoreResp.Holders, permits)
+}
+
+// maxOverlap は保持していた期間が最も多く重なった時点の保持者の数を返す
+func maxOverlap(responses []*SemaphoreResponse) (int, error) {
+	type event struct {
+		at    time.Time
+		delta int
+	}
+	events := make([]event, 0, len(responses)*2)
+	for _, resp := range responses {
+		acquiredAt, err := time.Parse(time.RFC3339Nano, resp.AcquiredAt)
+		if err != nil {
+			return 0, err
+		}
+		releasedAt, err := time.Parse(time.RFC3339Nano, resp.ReleasedAt)
+		if err != nil {
+			return 0, err
+		}
+		events = append(events, event{at: acquiredAt, delta: 1}, event{at: releasedAt, delta: -1})
+	}
+	// 同じ時刻の場合は解放を先に数える
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].at.Equal(events[j].at) {
+			return events[i].delta < events[j].delta
+		}
+		return events[i].at.Before(events[j].at)
+	})
+
+	current, peak := 0, 0
+	for _, e := range events {
+		current += e.delta
+		if current > peak {
+			peak = current
+		}
+	}
+	return peak, nil
+}
+
+// セマフォのテストを実行する関数
+// 許可の数より多いクライアントで並列に許可を得て、同時に許可を保持していたセッションが許可の数以下であることを確認する
+func RunSemaphoreTest(startID int, parallelCount int, permits int) bool {
+	name := "semaphore_" + uuid.New().String()
+	results := &semaphoreResults{}
+	RunParallel(startID, parallelCount, name, RunSemaphore, permits, results)
+
+	if results.failed > 0 {
+		fmt.Printf("Semaphore test failed: %d clients failed\n", results.failed)
+		return false
+	}
+
+	// サーバーが許可を得た時点で数えた保持者の数を確認
+	for _, resp := range results.responses {
+		if resp.Holders > permits {
+			fmt.Printf("Semaphore test failed: %d holders observed by session %s (permits: %d)\n", resp.Holders, resp.SessionID, permits)
+			return false
+		}
+	}
+
+	// 保持していた期間の重なりを確認（同じサーバーの時刻で比較する）
+	overlap, err := maxOverlap(results.responses)
+	if err != nil {
+		fmt.Printf("Failed to parse hold times: %v\n", err)
+		return false
+	}
+	fmt.Printf("Max concurrent holders: %d (permits: %d, clients: %d)\n", overlap, permits, parallelCount)
+	if overlap > permits {
+		fmt.Println("Semaphore test failed: more holders than permits at the same time")
+		return false
+	}
+
+	// すべて解放されていることを確認
+	status, err := NewClient(startID).GetSemaphoreStatus(name, permits)
+	if err != nil {
+		fmt.Printf("Failed to get semaphore status: %v\n", err)
+		return false
+	}
+	if status.InUse != 0 {
+		fmt.Printf("Semaphore test failed: %d slots still in use\n", status.InUse)
+		return false
+	}
+
+	fmt.Println("Semaphore test passed: at most permits holders at a time")
+	return true
+}
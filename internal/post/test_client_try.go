@@ -0,0 +1,123 @@
+package post
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// LockOwnerResponse はロック所有者レスポンスの構造体
+type LockOwnerResponse struct {
+	LockName         string `json:"lock_name"`
+	IsUsed           bool   `json:"is_used"`
+	OwnerSessionID   string `json:"owner_session_id,omitempty"`
+	IsOwnedBySession *bool  `json:"is_owned_by_session,omitempty"`
+}
+
+// LockFreeResponse はロック空き状況レスポンスの構造体
+type LockFreeResponse struct {
+	LockName string `json:"lock_name"`
+	IsFree   bool   `json:"is_free"`
+}
+
+// 待機せずにロックの取得を試みる
+// 他のセッションが保持している場合は acquired が false になる
+func (c *Client) TryLock(lockName string, ttl int) (lockResp *LockResponse, acquired bool, err error) {
+	reqBody := map[string]interface{}{
+		"lock_name": lockName,
+		"ttl":       ttl,
+	}
+
+	lockResp = &LockResponse{}
+	if err := c.postJSON("/api/locks/try", reqBody, lockResp); err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusLocked {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	return lockResp, true, nil
+}
+
+// ロックを保持しているセッションIDを取得する（sessionIDを指定した場合は、そのセッションが保持しているかも返す）
+func (c *Client) GetLockOwner(lockName string, sessionID string) (*LockOwnerResponse, error) {
+	path := "/api/locks/" + url.PathEscape(lockName) + "/owner"
+	if sessionID != "" {
+		path += "?session_id=" + url.QueryEscape(sessionID)
+	}
+
+	var ownerResp LockOwnerResponse
+	if err := c.getJSON(path, &ownerResp); err != nil {
+		return nil, err
+	}
+
+	return &ownerResp, nil
+}
+
+// ロックがどのセッションにも保持されていないかを取得する
+func (c *Client) IsLockFree(lockName string) (*LockFreeResponse, error) {
+	var freeResp LockFreeResponse
+	if err := c.getJSON("/api/locks/"+url.PathEscape(lockName)+"/free", &freeResp); err != nil {
+		return nil, err
+	}
+
+	return &freeResp, nil
+}
+
+// 待機なしのロックテスト（取得できたクライアントのみ保持し、それ以外はすぐに諦める）
+func RunTryLock(c *Client, lockName string, args ...interface{}) {
+	// 実行開始時間を記録
+	startTime := time.Now()
+
+	// ロックの取得を試みる
+	fmt.Printf("Client %d [%.1fs]: Trying lock...\n", c.ID, time.Since(startTime).Seconds())
+	lockResp, acquired, err := c.TryLock(lockName, 0)
+	if err != nil {
+		fmt.Printf("Client %d [%.1fs]: Try failed: %v\n", c.ID, time.Since(startTime).Seconds(), err)
+		return
+	}
+	if !acquired {
+		// 保持しているセッションを確認
+		owner, err := c.GetLockOwner(lockName, "")
+		if err != nil {
+			fmt.Printf("Client %d [%.1fs]: Lock busy, failed to get owner: %v\n", c.ID, time.Since(startTime).Seconds(), err)
+			return
+		}
+		fmt.Printf("Client %d [%.1fs]: Lock busy, held by session %s\n", c.ID, time.Since(startTime).Seconds(), owner.OwnerSessionID)
+		return
+	}
+	fmt.Printf("Client %d [%.1fs]: Acquired lock: %+v\n", c.ID, time.Since(startTime).Seconds(), lockResp)
+
+	// 保持中は自分のセッションが所有者であることを確認
+	owner, err := c.GetLockOwner(lockName, lockResp.SessionID)
+	if err != nil {
+		fmt.Printf("Client %d [%.1fs]: Failed to get owner: %v\n", c.ID, time.Since(startTime).Seconds(), err)
+	} else if owner.IsOwnedBySession == nil || !*owner.IsOwnedBySession {
+		fmt.Printf("Client %d [%.1fs]: Unexpected owner while held: %+v\n", c.ID, time.Since(startTime).Seconds(), owner)
+	}
+
+	time.Sleep(2 * time.Second)
+
+	// ロックを解放
+	if _, err := c.ReleaseLock(lockName, lockResp.LeaseID); err != nil {
+		fmt.Printf("Client %d [%.1fs]: Release failed: %v\n", c.ID, time.Since(startTime).Seconds(), err)
+		return
+	}
+
+	// 解放後は空いていることを確認
+	free, err := c.IsLockFree(lockName)
+	if err != nil {
+		fmt.Printf("Client %d [%.1fs]: Failed to check free: %v\n", c.ID, time.Since(startTime).Seconds(), err)
+		return
+	}
+	fmt.Printf("Client %d [%.1fs]: Released lock, is_free: %t\n", c.ID, time.Since(startTime).Seconds(), free.IsFree)
+}
+
+// 待機なしのロックテストを実行する関数
+func RunTryLockTest(startID int, parallelCount int) {
+	lockName := "try_lock"
+	RunParallel(startID, parallelCount, lockName, RunTryLock)
+}
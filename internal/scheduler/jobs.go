@@ -0,0 +1,85 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/example/named-lock/internal/config"
+	"github.com/example/named-lock/internal/db"
+)
+
+// defaultPurgeBatchSize は設定で削除件数が指定されていない場合に、古い注文を一度に削除する件数
+const defaultPurgeBatchSize = 1000
+
+// 既定のジョブの名前
+const (
+	JobReconcileInventory = "reconcile_inventory"
+	JobPurgeOrders        = "purge_old_orders"
+)
+
+// registerDefaultJobs は設定で実行予定が指定されている既定のジョブを登録する
+func (s *Scheduler) registerDefaultJobs(cfg *config.SchedulerConfig) error {
+	jobs := []struct {
+		name string
+		spec string
+		run  func(ctx context.Context, lock *db.Lock) (string, error)
+	}{
+		{JobReconcileInventory, cfg.ReconcileInventorySpec, s.reconcileInventory},
+		{JobPurgeOrders, cfg.PurgeOrdersSpec, func(ctx context.Context, lock *db.Lock) (string, error) {
+			return s.purgeOrders(ctx, cfg.OrderRetention, cfg.PurgeBatchSize)
+		}},
+	}
+
+	for _, job := range jobs {
+		if job.spec == "" {
+			continue
+		}
+		spec, err := ParseSpec(job.spec)
+		if err != nil {
+			return fmt.Errorf("job %s: %w", job.name, err)
+		}
+		if err := s.Register(Job{Name: job.name, Spec: spec, Timeout: cfg.JobTimeout, Run: job.run}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reconcileInventory は商品と注文を照合し、在庫切れの商品と、存在しない商品の注文を報告する
+func (s *Scheduler) reconcileInventory(ctx context.Context, lock *db.Lock) (string, error) {
+	summary, err := s.db.ReconcileInventory(ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(summary.OrphanOrderCodes) > 0 {
+		log.Printf("Warning: orders reference unknown products: %s", strings.Join(summary.OrphanOrderCodes, ", "))
+	}
+	return fmt.Sprintf("products: %d, out of stock: %d, orphan order codes: %d",
+		summary.Products, summary.OutOfStock, len(summary.OrphanOrderCodes)), nil
+}
+
+// purgeOrders は保持期間より古い注文を、batchSize 件ずつ削除する
+func (s *Scheduler) purgeOrders(ctx context.Context, retention time.Duration, batchSize int) (string, error) {
+	if batchSize <= 0 {
+		batchSize = defaultPurgeBatchSize
+	}
+	before := time.Now().Add(-retention)
+	var total int64
+	for {
+		deleted, err := s.db.PurgeOrders(ctx, before, batchSize)
+		if err != nil {
+			return "", fmt.Errorf("purged %d orders before failing: %w", total, err)
+		}
+		total += deleted
+		if deleted < int64(batchSize) || ctx.Err() != nil {
+			break
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return "", fmt.Errorf("purged %d orders before stopping: %w", total, err)
+	}
+	return fmt.Sprintf("purged %d orders created before %s", total, before.Format(time.RFC3339)), nil
+}
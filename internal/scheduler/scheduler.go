@@ -0,0 +1,252 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/example/named-lock/internal/config"
+	"github.com/example/named-lock/internal/db"
+	"github.com/samber/do"
+)
+
+// ErrJobNotFound は指定された名前のジョブが登録されていないことを表す
+var ErrJobNotFound = errors.New("job not found")
+
+// Job は定期実行ジョブを表す構造体
+type Job struct {
+	// Name はジョブの名前（ジョブのロック名と job_runs テーブルの記録に使う）
+	Name string
+	Spec Spec
+	// Timeout はひとつの実行の制限時間（0以下の場合は制限しない）
+	Timeout time.Duration
+	// Run はジョブの処理。戻り値の文字列は実行結果の概要として job_runs テーブルに記録する
+	// ctx はジョブのロックを失った時点でもキャンセルされる
+	Run func(ctx context.Context, lock *db.Lock) (string, error)
+}
+
+// JobInfo は登録されているジョブの状態を表す構造体
+type JobInfo struct {
+	Name    string
+	Spec    string
+	Timeout time.Duration
+	// NextRunAt はこのサーバーでの次の実行予定時刻（スケジューラーが開始していない場合はゼロ値）
+	NextRunAt time.Time
+}
+
+// JobLockName はジョブのロック名を返す
+func JobLockName(name string) string {
+	return db.DerivedLockName(name, "job")
+}
+
+// Scheduler は定期実行ジョブを実行する
+// ジョブは実行予定時刻ごとに、ジョブのロックを待機なし（GET_LOCK(name, 0)）で取得できたサーバーだけが実行する
+// ロックを取得した後、job_runs テーブルに同じ予定時刻の実行が記録されていないことを確認してから実行するため、
+// 他のサーバーの実行が終わった後にロックを取得した場合も、同じ予定時刻のジョブを重複して実行しない
+type Scheduler struct {
+	db       *db.DB
+	instance string
+
+	mu   sync.Mutex
+	jobs []*Job
+	// next はジョブの名前ごとの次の実行予定時刻
+	next map[string]time.Time
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewScheduler は新しいSchedulerインスタンスを作成し、設定に従って既定のジョブを登録する
+func NewScheduler(injector *do.Injector) (*Scheduler, error) {
+	database := do.MustInvoke[*db.DB](injector)
+	cfg := do.MustInvoke[*config.Config](injector)
+
+	hostname, _ := os.Hostname()
+	s := &Scheduler{
+		db:       database,
+		instance: fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		next:     make(map[string]time.Time),
+	}
+	if err := s.registerDefaultJobs(&cfg.Scheduler); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Register はジョブを登録する
+// Start の前に呼び出す（開始後に登録したジョブは実行されない）
+func (s *Scheduler) Register(job Job) error {
+	if job.Name == "" || job.Spec == nil || job.Run == nil {
+		return errors.New("job name, spec and run function are required")
+	}
+	if err := db.CheckBaseName(job.Name); err != nil {
+		return fmt.Errorf("job %s: %w", job.Name, err)
+	}
+	if job.Spec.Next(time.Now()).IsZero() {
+		return fmt.Errorf("job %s: schedule %q never runs", job.Name, job.Spec.String())
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, registered := range s.jobs {
+		if registered.Name == job.Name {
+			return fmt.Errorf("job %s is already registered", job.Name)
+		}
+	}
+	s.jobs = append(s.jobs, &job)
+	return nil
+}
+
+// Start は登録されているジョブの実行を開始する
+func (s *Scheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.loop(ctx, job)
+		}()
+	}
+	log.Printf("Scheduler started with %d jobs (instance: %s)", len(s.jobs), s.instance)
+}
+
+// Stop はジョブの実行を停止し、実行中のジョブが終了するまで待つ
+// 実行中のジョブのコンテキストはキャンセルされる
+func (s *Scheduler) Stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	s.wg.Wait()
+}
+
+// Jobs は登録されているジョブの一覧を返す
+func (s *Scheduler) Jobs() []JobInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs := make([]JobInfo, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, JobInfo{
+			Name:      job.Name,
+			Spec:      job.Spec.String(),
+			Timeout:   job.Timeout,
+			NextRunAt: s.next[job.Name],
+		})
+	}
+	return jobs
+}
+
+// Job は名前を指定して、登録されているジョブの状態を返す
+// 登録されていない場合は ErrJobNotFound を返す
+func (s *Scheduler) Job(name string) (JobInfo, error) {
+	for _, job := range s.Jobs() {
+		if job.Name == name {
+			return job, nil
+		}
+	}
+	return JobInfo{}, fmt.Errorf("%w: %s", ErrJobNotFound, name)
+}
+
+// loop は実行予定時刻まで待ってジョブを実行することを、コンテキストが終了するまで繰り返す
+func (s *Scheduler) loop(ctx context.Context, job *Job) {
+	for {
+		scheduledAt := job.Spec.Next(time.Now())
+		if scheduledAt.IsZero() {
+			log.Printf("Job %s: no more scheduled runs", job.Name)
+			return
+		}
+		s.mu.Lock()
+		s.next[job.Name] = scheduledAt
+		s.mu.Unlock()
+
+		timer := time.NewTimer(time.Until(scheduledAt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		s.run(ctx, job, scheduledAt)
+	}
+}
+
+// run はジョブのロックを待機なしで取得できた場合に、ジョブを実行して結果を記録する
+// ロックを他のサーバーが保持している場合や、同じ予定時刻の実行が記録済みの場合は実行しない
+func (s *Scheduler) run(ctx context.Context, job *Job, scheduledAt time.Time) {
+	lock, acquired, err := s.db.TryLock(ctx, JobLockName(job.Name))
+	if err != nil {
+		log.Printf("Job %s: failed to acquire job lock: %v", job.Name, err)
+		return
+	}
+	if !acquired {
+		log.Printf("Job %s: skipped, running on another server", job.Name)
+		return
+	}
+	// 停止中でも解放できるよう、キャンセルを引き継がないコンテキストを使う
+	defer lock.Release(context.WithoutCancel(ctx))
+
+	startedAt := time.Now()
+	runID, err := s.db.InsertJobRun(ctx, &db.JobRun{
+		JobName:      job.Name,
+		ScheduledAt:  scheduledAt,
+		StartedAt:    startedAt,
+		Outcome:      db.JobOutcomeRunning,
+		ConnectionID: lock.ConnectionID(),
+		Instance:     s.instance,
+	})
+	if errors.Is(err, db.ErrJobRunExists) {
+		log.Printf("Job %s: skipped, already ran for %s", job.Name, scheduledAt.Format(time.RFC3339))
+		return
+	}
+	if err != nil {
+		// 記録できない場合は、他のサーバーが重複して実行していないことを確かめられないため実行しない
+		log.Printf("Job %s: failed to record job run: %v", job.Name, err)
+		return
+	}
+
+	message, err := s.execute(ctx, job, lock)
+	duration := time.Since(startedAt)
+	outcome := db.JobOutcomeSucceeded
+	if err != nil {
+		outcome = db.JobOutcomeFailed
+		message = err.Error()
+	}
+	log.Printf("Job %s: %s in %s (connection ID: %d): %s", job.Name, outcome, duration, lock.ConnectionID(), message)
+
+	if err := s.db.FinishJobRun(context.WithoutCancel(ctx), runID, time.Now(), duration, outcome, message); err != nil {
+		log.Printf("Warning: job %s: %v", job.Name, err)
+	}
+}
+
+// execute はジョブのロックを失った時点、または制限時間でキャンセルされるコンテキストでジョブを実行する
+func (s *Scheduler) execute(ctx context.Context, job *Job, lock *db.Lock) (message string, err error) {
+	runCtx, cancel := lock.WithContext(ctx)
+	defer cancel()
+	if job.Timeout > 0 {
+		var cancelTimeout context.CancelFunc
+		runCtx, cancelTimeout = context.WithTimeout(runCtx, job.Timeout)
+		defer cancelTimeout()
+	}
+
+	defer func() {
+		// ジョブの不具合で他のジョブやサーバーが停止しないようにする
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	message, err = job.Run(runCtx, lock)
+	if err != nil && runCtx.Err() != nil {
+		// ロックを失った場合は ErrLockLost を含むエラーにする
+		err = fmt.Errorf("%w (cause: %w)", err, context.Cause(runCtx))
+	}
+	return message, err
+}
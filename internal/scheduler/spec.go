@@ -0,0 +1,199 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxCronSearch は cron 形式の次の実行時刻を探す期間の上限
+// 存在しない日付（2月31日など）だけを指定した場合に、探し続けないようにする
+const maxCronSearch = 5 * 366 * 24 * time.Hour
+
+// Spec はジョブの実行予定を表すインターフェース
+type Spec interface {
+	// Next は after より後の最初の実行予定時刻を返す（実行予定がない場合はゼロ値）
+	Next(after time.Time) time.Time
+	// String は実行予定の指定を返す
+	String() string
+}
+
+// ParseSpec はジョブの実行予定の指定を解析する
+// 次の形式を指定できる
+//   - @every <間隔>：一定の間隔（time.ParseDuration の形式。例: @every 10m）
+//   - @hourly、@daily：毎時0分、毎日0時0分
+//   - cron 形式の5つのフィールド（分 時 日 月 曜日）：各フィールドは *、数値、範囲（1-5）、間隔（*/15、0-30/10）、
+//     およびそれらのカンマ区切りで指定する。曜日は0（日曜日）〜6（7も日曜日）
+//
+// 時刻はサーバーのローカル時刻で判断する
+func ParseSpec(spec string) (Spec, error) {
+	spec = strings.TrimSpace(spec)
+	switch {
+	case strings.HasPrefix(spec, "@every "):
+		interval, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every ")))
+		if err != nil {
+			return nil, fmt.Errorf("invalid interval in %q: %w", spec, err)
+		}
+		return Every(interval)
+	case spec == "@hourly":
+		return parseCron(spec, "0 * * * *")
+	case spec == "@daily":
+		return parseCron(spec, "0 0 * * *")
+	}
+	return parseCron(spec, spec)
+}
+
+// everySpec は一定の間隔の実行予定
+type everySpec struct {
+	interval time.Duration
+}
+
+// Every は一定の間隔の実行予定を返す
+// 実行予定時刻は間隔で切り捨てた時刻（time.Time のゼロ時刻からの間隔の倍数）に揃えるため、
+// 複数のサーバーで同じジョブを登録しても、同じ時刻が実行予定になる
+func Every(interval time.Duration) (Spec, error) {
+	if interval < time.Second {
+		return nil, errors.New("interval must be at least 1s")
+	}
+	return &everySpec{interval: interval}, nil
+}
+
+// Next は after より後の、間隔の倍数の時刻を返す
+func (s *everySpec) Next(after time.Time) time.Time {
+	return after.Truncate(s.interval).Add(s.interval)
+}
+
+// String は実行予定の指定を返す
+func (s *everySpec) String() string {
+	return "@every " + s.interval.String()
+}
+
+// cronSpec は cron 形式の実行予定
+type cronSpec struct {
+	spec   string
+	minute []bool
+	hour   []bool
+	dom    []bool
+	month  []bool
+	dow    []bool
+	// domAny と dowAny は日と曜日が * か（両方とも指定した場合は、どちらかに一致すれば実行する）
+	domAny bool
+	dowAny bool
+}
+
+// parseCron は cron 形式の5つのフィールドを解析する
+// spec: String で返す元の指定
+func parseCron(spec string, fields string) (Spec, error) {
+	parts := strings.Fields(fields)
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("invalid schedule %q: expected 5 fields (minute hour day month weekday)", spec)
+	}
+
+	s := &cronSpec{spec: spec, domAny: parts[2] == "*", dowAny: parts[4] == "*"}
+	var err error
+	if s.minute, err = parseCronField(parts[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("invalid minute in %q: %w", spec, err)
+	}
+	if s.hour, err = parseCronField(parts[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("invalid hour in %q: %w", spec, err)
+	}
+	if s.dom, err = parseCronField(parts[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("invalid day of month in %q: %w", spec, err)
+	}
+	if s.month, err = parseCronField(parts[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("invalid month in %q: %w", spec, err)
+	}
+	if s.dow, err = parseCronField(parts[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("invalid day of week in %q: %w", spec, err)
+	}
+	// 7は日曜日として扱う
+	s.dow[0] = s.dow[0] || s.dow[7]
+	return s, nil
+}
+
+// parseCronField は cron 形式のひとつのフィールドを、lowest〜highest の各値に一致するかの表に変換する
+func parseCronField(field string, lowest int, highest int) ([]bool, error) {
+	values := make([]bool, highest+1)
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid step %q", stepPart)
+			}
+			step = n
+		}
+
+		start, end := lowest, highest
+		if rangePart != "*" {
+			first, last, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if start, err = strconv.Atoi(first); err != nil {
+				return nil, fmt.Errorf("invalid value %q", first)
+			}
+			end = start
+			if isRange {
+				if end, err = strconv.Atoi(last); err != nil {
+					return nil, fmt.Errorf("invalid value %q", last)
+				}
+			} else if hasStep {
+				// 5/15 は 5 から最大値までの15ごと
+				end = highest
+			}
+		}
+		if start < lowest || end > highest || start > end {
+			return nil, fmt.Errorf("value %q out of range %d-%d", rangePart, lowest, highest)
+		}
+
+		for v := start; v <= end; v += step {
+			values[v] = true
+		}
+	}
+	return values, nil
+}
+
+// Next は after より後の、すべてのフィールドに一致する最初の時刻（分単位）を返す
+// 一致しない月・日・時は、その単位でまとめて進める
+func (s *cronSpec) Next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(maxCronSearch)
+	for t.Before(limit) {
+		switch {
+		case !s.month[t.Month()]:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case !s.hour[t.Hour()]:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case !s.minute[t.Minute()]:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// dayMatches は日と曜日のフィールドに一致するかを返す
+// 日と曜日の両方を指定した場合は、どちらかに一致すれば一致とする（cron と同じ）
+func (s *cronSpec) dayMatches(t time.Time) bool {
+	dom := s.dom[t.Day()]
+	dow := s.dow[t.Weekday()]
+	switch {
+	case s.domAny && s.dowAny:
+		return true
+	case s.domAny:
+		return dow
+	case s.dowAny:
+		return dom
+	}
+	return dom || dow
+}
+
+// String は実行予定の指定を返す
+func (s *cronSpec) String() string {
+	return s.spec
+}
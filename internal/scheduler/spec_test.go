@@ -0,0 +1,85 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSpec(t *testing.T) {
+	tests := []struct {
+		spec    string
+		wantErr bool
+	}{
+		{"@every 10m", false},
+		{"  @every 1s  ", false},
+		{"@hourly", false},
+		{"@daily", false},
+		{"*/15 * * * *", false},
+		{"0 9 * * 1-5", false},
+		{"0,30 0-6/2 1 1,7 0", false},
+		{"5/15 * * * 7", false},
+		{"@every", true},
+		{"@every 10", true},
+		{"@every 500ms", true},
+		{"@weekly", true},
+		{"* * * *", true},
+		{"* * * * * *", true},
+		{"60 * * * *", true},
+		{"* 24 * * *", true},
+		{"* * 0 * *", true},
+		{"* * * 13 *", true},
+		{"* * * * 8", true},
+		{"5-1 * * * *", true},
+		{"*/0 * * * *", true},
+		{"a * * * *", true},
+		{"1-b * * * *", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			spec, err := ParseSpec(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSpec(%q) error = %v, want error: %t", tt.spec, err, tt.wantErr)
+			}
+			if err == nil && spec.String() == "" {
+				t.Errorf("ParseSpec(%q).String() is empty", tt.spec)
+			}
+		})
+	}
+}
+
+func TestSpecNext(t *testing.T) {
+	// 2026-03-04 は水曜日（@every の切り捨てはタイムゾーンのオフセットに影響されるため UTC で確認する）
+	after := time.Date(2026, 3, 4, 10, 7, 30, 0, time.UTC)
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"@every 10m", time.Date(2026, 3, 4, 10, 10, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, 3, 4, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC)},
+		{"* * * * *", time.Date(2026, 3, 4, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 3, 4, 10, 15, 0, 0, time.UTC)},
+		{"5/20 * * * *", time.Date(2026, 3, 4, 10, 25, 0, 0, time.UTC)},
+		{"0 9 * * *", time.Date(2026, 3, 5, 9, 0, 0, 0, time.UTC)},
+		{"30 8 * * 1-5", time.Date(2026, 3, 5, 8, 30, 0, 0, time.UTC)},
+		// 7も日曜日
+		{"0 0 * * 7", time.Date(2026, 3, 8, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+		// 日と曜日の両方を指定した場合は、どちらかに一致すれば実行する
+		{"0 0 10 * 5", time.Date(2026, 3, 6, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// 存在しない日付だけを指定した場合は実行予定がない
+		{"0 0 31 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			spec, err := ParseSpec(tt.spec)
+			if err != nil {
+				t.Fatalf("ParseSpec(%q) error = %v", tt.spec, err)
+			}
+			if got := spec.Next(after); !got.Equal(tt.want) {
+				t.Errorf("Next(%v) = %v, want %v", after, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/example/named-lock/internal/db"
+	"github.com/example/named-lock/internal/scheduler"
+	"github.com/samber/do"
+)
+
+// AdminService はロックの管理操作に関するサービス
+type AdminService struct {
+	db        *db.DB
+	scheduler *scheduler.Scheduler
+}
+
+// NewAdminService は新しいAdminServiceインスタンスを作成する
+func NewAdminService(injector *do.Injector) (*AdminService, error) {
+	database := do.MustInvoke[*db.DB](injector)
+	jobScheduler := do.MustInvoke[*scheduler.Scheduler](injector)
+	return &AdminService{
+		db:        database,
+		scheduler: jobScheduler,
+	}, nil
+}
+
+// DatabaseAvailable はデータベースに接続しているかを返す
+func (s *AdminService) DatabaseAvailable() bool {
+	return s.db != nil
+}
+
+// ListLocks は名前付きロックの保持者と待機者の一覧を返す
+// performance_schema を使用できない場合は、このサーバーが保持しているロックのみを返す
+func (s *AdminService) ListLocks(ctx context.Context) (*db.LockSnapshot, error) {
+	return s.db.ListUserLocks(ctx)
+}
+
+// ForceReleaseRequest は名前付きロックの強制解放の要求を表す構造体
+type ForceReleaseRequest struct {
+	LockName string
+	// Reason は強制解放の理由（lock_admin_actions テーブルに記録する）
+	Reason string
+	// DryRun の場合は所有者の確認と記録のみを行い、KILL は発行しない
+	DryRun bool
+	// QueryOnly の場合は接続を切断せず、実行中のクエリのみを打ち切る（ロックは解放されない）
+	QueryOnly bool
+}
+
+// ForceReleaseResult は名前付きロックの強制解放の結果を表す構造体
+type ForceReleaseResult struct {
+	// ActionID は lock_admin_actions テーブルの記録のID
+	ActionID int64
+	Action   string
+	Result   string
+	Owner    *db.LockOwner
+}
+
+// ForceRelease はロックを保持しているセッションを IS_USED_LOCK で特定し、接続（またはクエリ）を KILL する
+// 所有者がアプリケーションのユーザーでない（または PROCESSLIST で確認できない）場合は
+// KILL せずに db.ErrForeignConnection を返す
+// 拒否した場合も含め、操作は理由とともに lock_admin_actions テーブルに記録する
+// 記録できない場合は KILL を発行しない
+func (s *AdminService) ForceRelease(ctx context.Context, req ForceReleaseRequest) (*ForceReleaseResult, error) {
+	action := &db.AdminAction{
+		LockName: req.LockName,
+		Key:      s.db.LockKey(req.LockName),
+		Action:   db.AdminActionKillConnection,
+		DryRun:   req.DryRun,
+		Reason:   req.Reason,
+	}
+	if req.QueryOnly {
+		action.Action = db.AdminActionKillQuery
+	}
+
+	owner, err := s.db.FindLockOwner(ctx, req.LockName)
+	if err != nil {
+		if !errors.Is(err, db.ErrLockNotHeld) {
+			return nil, err
+		}
+		action.Result = db.AdminResultNotHeld
+		if _, recordErr := s.db.InsertAdminAction(ctx, action); recordErr != nil {
+			return nil, recordErr
+		}
+		return nil, err
+	}
+	action.ConnectionID = owner.Session.ConnectionID
+	action.OwnerUser = owner.Session.User
+	action.OwnerHost = owner.Session.Host
+
+	appUser, err := s.db.ApplicationUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !owner.Visible || owner.Session.User != appUser {
+		action.Result = db.AdminResultRefused
+		action.Error = db.ErrForeignConnection.Error()
+		if _, recordErr := s.db.InsertAdminAction(ctx, action); recordErr != nil {
+			return nil, recordErr
+		}
+		log.Printf("Refused to force release lock: %s, session ID: %d, user: %q", req.LockName, owner.Session.ConnectionID, owner.Session.User)
+		return nil, fmt.Errorf("failed to force release lock %s (session ID: %d): %w", req.LockName, owner.Session.ConnectionID, db.ErrForeignConnection)
+	}
+
+	result := &ForceReleaseResult{Action: action.Action, Owner: owner}
+	if req.DryRun {
+		action.Result = db.AdminResultDryRun
+		result.Result = action.Result
+		result.ActionID, err = s.db.InsertAdminAction(ctx, action)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+
+	// KILL の前に記録する（記録できない場合は KILL しない）
+	action.Result = db.AdminResultPending
+	result.ActionID, err = s.db.InsertAdminAction(ctx, action)
+	if err != nil {
+		return nil, err
+	}
+
+	killErr := s.db.KillLockOwner(ctx, owner, req.QueryOnly)
+	switch {
+	case killErr == nil:
+		result.Result = db.AdminResultKilled
+	case errors.Is(killErr, db.ErrLockNotHeld):
+		// 確認してから KILL するまでの間に解放された
+		result.Result = db.AdminResultNotHeld
+	default:
+		result.Result = db.AdminResultFailed
+	}
+	var errMessage string
+	if killErr != nil {
+		errMessage = killErr.Error()
+	}
+	// KILL は発行済みのため、リクエストのキャンセルで結果の記録を中断しない
+	if err := s.db.UpdateAdminActionResult(context.WithoutCancel(ctx), result.ActionID, result.Result, errMessage); err != nil {
+		log.Printf("Failed to record force release result: %s, action ID: %d: %v", req.LockName, result.ActionID, err)
+	}
+	if killErr != nil {
+		return nil, killErr
+	}
+
+	log.Printf("Force released lock: %s, session ID: %d, action: %s, reason: %s", req.LockName, owner.Session.ConnectionID, action.Action, req.Reason)
+	return result, nil
+}
+
+// JobStatus は定期実行ジョブの状態を表す構造体
+type JobStatus struct {
+	scheduler.JobInfo
+	// HolderSessionID はジョブのロックを保持しているセッションID（実行中でない場合は0）
+	// 他のサーバーが実行している場合も含む
+	HolderSessionID int64
+	// LastRun は最後の実行記録（実行記録がない場合は nil）
+	LastRun *db.JobRun
+}
+
+// ListJobs は登録されている定期実行ジョブの一覧を、最後の実行記録とともに返す
+func (s *AdminService) ListJobs(ctx context.Context) ([]JobStatus, error) {
+	latest, err := s.db.LatestJobRuns(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := s.scheduler.Jobs()
+	statuses := make([]JobStatus, 0, len(jobs))
+	for _, job := range jobs {
+		holder, _, err := s.db.IsUsedLock(ctx, scheduler.JobLockName(job.Name))
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, JobStatus{JobInfo: job, HolderSessionID: holder, LastRun: latest[job.Name]})
+	}
+	return statuses, nil
+}
+
+// ListJobRuns は定期実行ジョブの実行記録を新しい順に最大 limit 件返す
+// ジョブが登録されていない場合は scheduler.ErrJobNotFound を返す
+func (s *AdminService) ListJobRuns(ctx context.Context, name string, limit int) ([]*db.JobRun, error) {
+	if _, err := s.scheduler.Job(name); err != nil {
+		return nil, err
+	}
+	return s.db.ListJobRuns(ctx, name, limit)
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/example/named-lock/internal/db"
+	"github.com/google/uuid"
+)
+
+// 注文明細が受け付けられなかった理由
+const (
+	CheckoutReasonProductNotFound   = "product_not_found"
+	CheckoutReasonInsufficientStock = "insufficient_stock"
+)
+
+// CheckoutLine は注文明細（商品コードと数量）を表す構造体
+type CheckoutLine struct {
+	ProductCode string
+	Quantity    int
+}
+
+// CheckoutLineError は受け付けられなかった注文明細を表す構造体
+type CheckoutLineError struct {
+	// Index はリクエスト中の明細の位置
+	Index       int
+	ProductCode string
+	Quantity    int
+	// Available は明細を処理する時点で引き当て可能だった在庫数（同じ商品の前の明細の分は差し引く）
+	Available int
+	Reason    string
+}
+
+// CheckoutError は在庫不足などで注文全体を受け付けなかったことを表すエラー
+// どの明細も在庫・注文に反映されていない
+type CheckoutError struct {
+	Lines []CheckoutLineError
+}
+
+// Error はエラーメッセージを返す
+func (e *CheckoutError) Error() string {
+	messages := make([]string, 0, len(e.Lines))
+	for _, line := range e.Lines {
+		messages = append(messages, fmt.Sprintf("%s: %s (requested: %d, available: %d)", line.ProductCode, line.Reason, line.Quantity, line.Available))
+	}
+	return "checkout rejected: " + strings.Join(messages, ", ")
+}
+
+// CheckoutOrder は注文明細ごとに作成した注文を表す構造体
+type CheckoutOrder struct {
+	OrderID     string
+	ProductCode string
+	Quantity    int
+	// Remaining は注文後の在庫数
+	Remaining int
+}
+
+// Checkout は複数商品の注文をひとつのトランザクションで処理する
+// すべての商品のロックを名前順に取得し（デッドロックしない順序）、在庫を確認してから
+// 在庫を減らし、明細ごとに注文を挿入する
+// ひとつでも在庫が足りない明細がある場合は何も反映せず、明細ごとの理由を持つ *CheckoutError を返す
+func (s *LockService) Checkout(ctx context.Context, lines []CheckoutLine, timeout int) ([]CheckoutOrder, error) {
+	id := uuid.New().String()
+
+	codes := make([]string, 0, len(lines))
+	for _, line := range lines {
+		codes = append(codes, line.ProductCode)
+	}
+
+	var orders []CheckoutOrder
+	err := s.db.WithNamedLocks(ctx, codes, timeout, func(tx *db.Tx) error {
+		// 在庫情報を取得（FOR UPDATE句を使用）し、明細ごとに引き当てる
+		products := make(map[string]*db.Product)
+		var updated []*db.Product
+		var failures []CheckoutLineError
+		for i, line := range lines {
+			product, ok := products[line.ProductCode]
+			if !ok {
+				var err error
+				product, err = tx.GetProductForUpdate(line.ProductCode)
+				if err != nil {
+					return fmt.Errorf("failed to get product: %w", err)
+				}
+				products[line.ProductCode] = product
+				if product != nil {
+					fmt.Printf("[%s] Found existing product ID: %s, current quantity: %d\n", id, product.Code, product.Quantity)
+					updated = append(updated, product)
+				}
+			}
+
+			switch {
+			case product == nil:
+				failures = append(failures, CheckoutLineError{
+					Index:       i,
+					ProductCode: line.ProductCode,
+					Quantity:    line.Quantity,
+					Reason:      CheckoutReasonProductNotFound,
+				})
+			case product.Quantity < line.Quantity:
+				failures = append(failures, CheckoutLineError{
+					Index:       i,
+					ProductCode: line.ProductCode,
+					Quantity:    line.Quantity,
+					Available:   product.Quantity,
+					Reason:      CheckoutReasonInsufficientStock,
+				})
+			default:
+				product.Quantity -= line.Quantity
+				orders = append(orders, CheckoutOrder{
+					OrderID:     uuid.New().String(),
+					ProductCode: line.ProductCode,
+					Quantity:    line.Quantity,
+					Remaining:   product.Quantity,
+				})
+			}
+		}
+		if len(failures) > 0 {
+			// ロールバックされるため、引き当てた明細も反映されない
+			orders = nil
+			return &CheckoutError{Lines: failures}
+		}
+
+		// 在庫を減らす
+		for _, product := range updated {
+			if err := tx.UpdateInventory(product); err != nil {
+				return fmt.Errorf("failed to update product: %w", err)
+			}
+			fmt.Printf("[%s] Updated product quantity to: %d (product ID: %s)\n", id, product.Quantity, product.Code)
+		}
+
+		// 明細ごとに注文を挿入する
+		for _, order := range orders {
+			newOrder := &db.Order{
+				ID:       order.OrderID,
+				Code:     order.ProductCode,
+				Quantity: order.Quantity,
+			}
+			if err := tx.InsertOrder(newOrder); err != nil {
+				return fmt.Errorf("failed to insert order: %w", err)
+			}
+			fmt.Printf("[%s] Inserted new order with ID: %s, Code: %s, Quantity: %d\n", id, newOrder.ID, newOrder.Code, newOrder.Quantity)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("[%s] Transaction committed and lock released\n", id)
+
+	return orders, nil
+}
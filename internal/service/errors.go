@@ -0,0 +1,35 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInsufficientStock は在庫が足りないため在庫を減らせなかったことを表す（errors.Is で判定する）
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+// ErrDatabaseUnavailable はデータベースに接続せずに起動したため、データベースを使う処理を実行できないことを表す
+// 保持・解放のバックエンドがMySQLでない場合は、MySQLに接続できなくても起動する（locker.NewDatabase）
+var ErrDatabaseUnavailable = errors.New("database is unavailable")
+
+// ErrInvalidQuantity は在庫を減らす数量が1未満であることを表す（errors.Is で判定する）
+var ErrInvalidQuantity = errors.New("quantity must be greater than 0")
+
+// InsufficientStockError は在庫が足りない商品と、その時点の在庫数を表すエラー
+type InsufficientStockError struct {
+	ProductCode string
+	// Requested は減らそうとした数量
+	Requested int
+	// Current はロックを取得した時点の在庫数（商品が存在しない場合は0）
+	Current int
+}
+
+// Error はエラーメッセージを返す
+func (e *InsufficientStockError) Error() string {
+	return fmt.Sprintf("%v: product %q (requested: %d, current: %d)", ErrInsufficientStock, e.ProductCode, e.Requested, e.Current)
+}
+
+// Unwrap は errors.Is で ErrInsufficientStock と判定できるよう、エラーの種類を返す
+func (e *InsufficientStockError) Unwrap() error {
+	return ErrInsufficientStock
+}
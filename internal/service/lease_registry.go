@@ -0,0 +1,284 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/example/named-lock/internal/config"
+	"github.com/example/named-lock/internal/db"
+)
+
+// リースの状態
+const (
+	LeaseStateActive  = "active"
+	LeaseStateExpired = "expired"
+	// LeaseStateLost はセッションの切断などでロックを失ったリース
+	LeaseStateLost = "lost"
+)
+
+// leaseReleaseTimeout は期限切れ・停止時にロックを解放する際のタイムアウト
+const leaseReleaseTimeout = 5 * time.Second
+
+// Lease はHTTPリクエストをまたいで保持される名前付きロックを表す構造体
+// ロックを取得した接続をサーバー側で固定し、解放されるか期限切れになるまでプールに戻さない
+type Lease struct {
+	ID        string
+	LockName  string
+	SessionID string
+	// FenceToken はロックを取得した際に発行したフェンシングトークン
+	FenceToken int64
+	AcquiredAt time.Time
+	ExpiresAt  time.Time
+	lock       *db.Lock
+	timer      *time.Timer
+	// unwatch はロックを失ったかどうかの監視を停止する
+	unwatch context.CancelFunc
+}
+
+// LeaseInfo はリースの状態を表す構造体（状態一覧で使用する）
+type LeaseInfo struct {
+	ID         string
+	LockName   string
+	SessionID  string
+	FenceToken int64
+	State      string
+	AcquiredAt time.Time
+	ExpiresAt  time.Time
+	// EndedAt は解放または期限切れになった時刻（保持中はゼロ値）
+	EndedAt time.Time
+}
+
+// LeaseRegistry は固定した接続をTTL付きで管理する
+// TTLを過ぎたリースは RELEASE_LOCK を実行した上で接続を閉じる
+type LeaseRegistry struct {
+	defaultTTL  time.Duration
+	maxTTL      time.Duration
+	historySize int
+
+	mu      sync.Mutex
+	byName  map[string]*Lease
+	byID    map[string]*Lease
+	expired []LeaseInfo
+}
+
+// NewLeaseRegistry は新しいLeaseRegistryインスタンスを作成する
+func NewLeaseRegistry(cfg *config.LockConfig) *LeaseRegistry {
+	return &LeaseRegistry{
+		defaultTTL:  cfg.DefaultLeaseTTL,
+		maxTTL:      cfg.MaxLeaseTTL,
+		historySize: cfg.ExpiredHistorySize,
+		byName:      make(map[string]*Lease),
+		byID:        make(map[string]*Lease),
+	}
+}
+
+// resolveTTL は指定されたTTLを検証し、未指定の場合は既定値を返す
+func (r *LeaseRegistry) resolveTTL(ttl time.Duration) (time.Duration, error) {
+	if ttl <= 0 {
+		return r.defaultTTL, nil
+	}
+	if r.maxTTL > 0 && ttl > r.maxTTL {
+		return 0, fmt.Errorf("ttl %s exceeds maximum %s", ttl, r.maxTTL)
+	}
+	return ttl, nil
+}
+
+// Register はリースを登録し、TTL経過後に期限切れとなるようにする
+func (r *LeaseRegistry) Register(lease *Lease, ttl time.Duration) (LeaseInfo, error) {
+	ttl, err := r.resolveTTL(ttl)
+	if err != nil {
+		return LeaseInfo{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.byName[lease.LockName]; ok {
+		return LeaseInfo{}, fmt.Errorf("lease already registered for lock: %s", lease.LockName)
+	}
+
+	lease.AcquiredAt = time.Now()
+	lease.ExpiresAt = lease.AcquiredAt.Add(ttl)
+	lease.timer = time.AfterFunc(ttl, func() { r.expire(lease.ID) })
+	lease.unwatch = r.watchLost(lease)
+	r.byName[lease.LockName] = lease
+	r.byID[lease.ID] = lease
+
+	return lease.info(LeaseStateActive), nil
+}
+
+// Renew はリースの期限をTTLだけ延長する
+func (r *LeaseRegistry) Renew(leaseID string, ttl time.Duration) (LeaseInfo, error) {
+	ttl, err := r.resolveTTL(ttl)
+	if err != nil {
+		return LeaseInfo{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	lease, ok := r.byID[leaseID]
+	if !ok {
+		return LeaseInfo{}, fmt.Errorf("lease not found: %s: %w", leaseID, db.ErrLockNotHeld)
+	}
+
+	lease.ExpiresAt = time.Now().Add(ttl)
+	lease.timer.Reset(ttl)
+
+	return lease.info(LeaseStateActive), nil
+}
+
+// Get はロック名に対応するリースを返す
+func (r *LeaseRegistry) Get(lockName string) (*Lease, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	lease, ok := r.byName[lockName]
+	return lease, ok
+}
+
+// Remove はリースの登録を解除する（ロックの解放は呼び出し元で行う）
+// leaseIDが空でない場合は、リースIDが一致する場合のみ解除する
+func (r *LeaseRegistry) Remove(lockName string, leaseID string) (*Lease, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	lease, ok := r.byName[lockName]
+	if !ok {
+		return nil, fmt.Errorf("lock is not held by this server: %s: %w", lockName, db.ErrLockNotHeld)
+	}
+	if leaseID != "" && lease.ID != leaseID {
+		return lease, fmt.Errorf("lease id mismatch for lock: %s: %w", lockName, db.ErrLockNotHeld)
+	}
+
+	r.remove(lease)
+	return lease, nil
+}
+
+// List は保持中のリースと、直近で期限切れになったリース・ロックを失ったリースの一覧を返す
+func (r *LeaseRegistry) List() []LeaseInfo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	infos := make([]LeaseInfo, 0, len(r.byName)+len(r.expired))
+	for _, lease := range r.byName {
+		infos = append(infos, lease.info(LeaseStateActive))
+	}
+	infos = append(infos, r.expired...)
+	return infos
+}
+
+// Close は保持中のすべてのリースを解放する（サーバー停止時に使用する）
+func (r *LeaseRegistry) Close() {
+	r.mu.Lock()
+	leases := make([]*Lease, 0, len(r.byName))
+	for _, lease := range r.byName {
+		r.remove(lease)
+		leases = append(leases, lease)
+	}
+	r.mu.Unlock()
+
+	for _, lease := range leases {
+		ctx, cancel := context.WithTimeout(context.Background(), leaseReleaseTimeout)
+		if err := lease.release(ctx); err != nil {
+			log.Printf("[%s] Failed to release lease on shutdown: %s: %v", lease.ID, lease.LockName, err)
+		}
+		cancel()
+	}
+}
+
+// expire はTTLを過ぎたリースのロックを解放し、接続を閉じる
+func (r *LeaseRegistry) expire(leaseID string) {
+	r.mu.Lock()
+	lease, ok := r.byID[leaseID]
+	// 解放済み、またはタイマー発火と同時に延長された場合は何もしない
+	if !ok || time.Now().Before(lease.ExpiresAt) {
+		r.mu.Unlock()
+		return
+	}
+	r.remove(lease)
+	info := r.recordEnded(lease, LeaseStateExpired)
+	r.mu.Unlock()
+
+	log.Printf("[%s] Lease expired: %s, session ID: %s, held for %s", lease.ID, lease.LockName, lease.SessionID, info.EndedAt.Sub(lease.AcquiredAt))
+
+	ctx, cancel := context.WithTimeout(context.Background(), leaseReleaseTimeout)
+	defer cancel()
+	if err := lease.release(ctx); err != nil {
+		log.Printf("[%s] Failed to release expired lease: %s: %v", lease.ID, lease.LockName, err)
+	}
+}
+
+// watchLost はリースのロックを失った場合に lose を呼び出すよう監視を開始し、監視を停止する関数を返す
+func (r *LeaseRegistry) watchLost(lease *Lease) context.CancelFunc {
+	ctx, cancel := lease.lock.WithContext(context.Background())
+	context.AfterFunc(ctx, func() {
+		if errors.Is(context.Cause(ctx), db.ErrLockLost) {
+			r.lose(lease.ID)
+		}
+	})
+	return cancel
+}
+
+// lose はロックを失ったリースを削除し、接続を破棄する
+func (r *LeaseRegistry) lose(leaseID string) {
+	r.mu.Lock()
+	lease, ok := r.byID[leaseID]
+	if !ok {
+		r.mu.Unlock()
+		return
+	}
+	r.remove(lease)
+	info := r.recordEnded(lease, LeaseStateLost)
+	r.mu.Unlock()
+
+	log.Printf("[%s] Lease lost: %s, session ID: %s, held for %s", lease.ID, lease.LockName, lease.SessionID, info.EndedAt.Sub(lease.AcquiredAt))
+
+	// ロックは既に失っているため、解放に失敗した接続は破棄される
+	ctx, cancel := context.WithTimeout(context.Background(), leaseReleaseTimeout)
+	defer cancel()
+	if err := lease.release(ctx); err != nil && !errors.Is(err, db.ErrLockNotHeld) {
+		log.Printf("[%s] Failed to release lost lease: %s: %v", lease.ID, lease.LockName, err)
+	}
+}
+
+// recordEnded は終了したリースを履歴に追加する（r.muを保持した状態で呼び出す）
+func (r *LeaseRegistry) recordEnded(lease *Lease, state string) LeaseInfo {
+	info := lease.info(state)
+	info.EndedAt = time.Now()
+	r.expired = append(r.expired, info)
+	if len(r.expired) > r.historySize {
+		r.expired = r.expired[len(r.expired)-r.historySize:]
+	}
+	return info
+}
+
+// remove はリースをマップから削除し、タイマーを停止する（r.muを保持した状態で呼び出す）
+func (r *LeaseRegistry) remove(lease *Lease) {
+	lease.timer.Stop()
+	lease.unwatch()
+	delete(r.byName, lease.LockName)
+	delete(r.byID, lease.ID)
+}
+
+// info はリースの状態を LeaseInfo として返す
+func (l *Lease) info(state string) LeaseInfo {
+	return LeaseInfo{
+		ID:         l.ID,
+		LockName:   l.LockName,
+		SessionID:  l.SessionID,
+		FenceToken: l.FenceToken,
+		State:      state,
+		AcquiredAt: l.AcquiredAt,
+		ExpiresAt:  l.ExpiresAt,
+	}
+}
+
+// release はロックを解放し、接続をプールに戻す
+func (l *Lease) release(ctx context.Context) error {
+	return l.lock.Release(ctx)
+}
@@ -2,10 +2,13 @@ package service
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"time"
 
+	"github.com/example/named-lock/internal/config"
 	"github.com/example/named-lock/internal/db"
+	"github.com/example/named-lock/internal/locker"
 	"github.com/google/uuid"
 	"github.com/samber/do"
 )
@@ -13,16 +16,35 @@ import (
 // LockService はロック操作に関するサービス
 type LockService struct {
 	db *db.DB
+	// locker はロックのみを扱う処理（保持・解放の一連の操作）で使うバックエンド（LockerConfig.HoldReleaseBackend）
+	locker locker.Backend
+	// leases はHTTPリクエストをまたいで保持しているロック
+	leases *LeaseRegistry
 }
 
 // NewLockService は新しいLockServiceインスタンスを作成する
 func NewLockService(injector *do.Injector) (*LockService, error) {
 	database := do.MustInvoke[*db.DB](injector)
+	cfg := do.MustInvoke[*config.Config](injector)
+	backend := do.MustInvoke[locker.Backend](injector)
 	return &LockService{
-		db: database,
+		db:     database,
+		locker: backend,
+		leases: NewLeaseRegistry(&cfg.Lock),
 	}, nil
 }
 
+// DatabaseAvailable はデータベースに接続しているかを返す
+// 接続していない場合（MySQLを使わないバックエンドで、MySQLに接続できずに起動した場合）は保持・解放のみを使用できる
+func (s *LockService) DatabaseAvailable() bool {
+	return s.db != nil
+}
+
+// Close は保持中のリースをすべて解放する
+func (s *LockService) Close() {
+	s.leases.Close()
+}
+
 // GetCurrentSessionID は現在のセッションIDを取得する
 func (s *LockService) GetCurrentSessionID() (string, error) {
 	sessionID, err := s.db.GetCurrentConnectionID()
@@ -32,199 +54,396 @@ func (s *LockService) GetCurrentSessionID() (string, error) {
 	return fmt.Sprintf("%d", sessionID), nil
 }
 
-// AcquireHoldReleaseLock はロックを取得し、指定された時間保持した後、解放する
-// ロックの取得と解放の間にトランザクションを張る
-func (s *LockService) AcquireHoldReleaseLock(ctx context.Context, lockName string, timeout int, holdDuration int) (string, error) {
-	id := uuid.New().String()
-
-	// トランザクションを開始
-	tx, err := s.db.BeginTx(ctx)
+// AcquireLock はロックを取得し、解放されるか期限切れになるまで接続を保持する
+// 戻り値のリースIDを使って別のリクエストからロックを延長・解放できる
+// ttlが0以下の場合は既定のリース期間を使用する
+// fair の場合は順番待ち（公平モード）で取得し、順番待ちの統計を返す（それ以外の場合は nil）
+func (s *LockService) AcquireLock(ctx context.Context, lockName string, timeout int, ttl time.Duration, fair bool) (LeaseInfo, *db.QueueStats, error) {
+	waitCtx, cancel := db.LockWaitContext(ctx, timeout)
+	var lock *db.Lock
+	var stats *db.QueueStats
+	var err error
+	if fair {
+		lock, stats, err = s.db.AcquireFair(waitCtx, lockName)
+	} else {
+		lock, err = s.db.AcquireLock(waitCtx, lockName)
+	}
+	cancel()
 	if err != nil {
-		return "", fmt.Errorf("failed to begin transaction: %w", err)
+		return LeaseInfo{}, stats, err
 	}
-	defer tx.Rollback()
 
-	sID, err := tx.GetCurrentConnectionID()
+	info, err := s.registerLease(ctx, lock, ttl)
+	return info, stats, err
+}
+
+// TryLock は待機せずにロックの取得を試み、取得できた場合はAcquireLockと同様にリースとして保持する
+// 他のセッションが保持している場合は false を返す
+func (s *LockService) TryLock(ctx context.Context, lockName string, ttl time.Duration) (LeaseInfo, bool, error) {
+	lock, acquired, err := s.db.TryLock(ctx, lockName)
 	if err != nil {
-		return "", fmt.Errorf("failed to get connection id: %w", err)
+		return LeaseInfo{}, false, err
+	}
+	if !acquired {
+		return LeaseInfo{}, false, nil
 	}
-	fmt.Printf("[%s]before lock session ID:%d", id, sID)
-	sessionID := fmt.Sprintf("%d", sID)
 
-	// ロックを取得
-	result, err := tx.GetNamedLock(lockName, timeout)
+	info, err := s.registerLease(ctx, lock, ttl)
 	if err != nil {
-		return sessionID, fmt.Errorf("failed to acquire lock: %w", err)
-	}
-	// ロック取得に失敗した場合
-	if !result {
-		return sessionID, fmt.Errorf("failed to acquire lock: result %v", result)
+		return LeaseInfo{}, false, err
 	}
+	return info, true, nil
+}
 
-	sID, err = tx.GetCurrentConnectionID()
+// registerLease は取得したロックをリースとして登録する
+// 登録できなかった場合はロックを解放する
+func (s *LockService) registerLease(ctx context.Context, lock *db.Lock, ttl time.Duration) (LeaseInfo, error) {
+	lease := &Lease{
+		ID:         uuid.New().String(),
+		LockName:   lock.Name(),
+		SessionID:  fmt.Sprintf("%d", lock.ConnectionID()),
+		FenceToken: lock.FenceToken(),
+		lock:       lock,
+	}
+	info, err := s.leases.Register(lease, ttl)
 	if err != nil {
-		return "", fmt.Errorf("failed to get connection id: %w", err)
+		lease.release(ctx)
+		return LeaseInfo{}, fmt.Errorf("failed to register lease: %w", err)
 	}
-	fmt.Printf("[%s]after lock session ID:%d", id, sID)
 
-	// 指定された時間だけ待機
-	time.Sleep(time.Duration(holdDuration) * time.Second)
+	fmt.Printf("[%s] Lock acquired: %s, session ID: %s, fence token: %d, expires at: %s\n", lease.ID, lease.LockName, lease.SessionID, lease.FenceToken, info.ExpiresAt.Format(time.RFC3339))
+
+	return info, nil
+}
+
+// IsFreeLock はロックがどのセッションにも保持されていないかを返す
+func (s *LockService) IsFreeLock(ctx context.Context, lockName string) (bool, error) {
+	return s.db.IsFreeLock(ctx, lockName)
+}
 
-	sID, err = tx.GetCurrentConnectionID()
+// IsUsedLock はロックを保持しているセッションIDを返す
+// 保持しているセッションがない場合は空文字列を返す
+func (s *LockService) IsUsedLock(ctx context.Context, lockName string) (string, error) {
+	owner, used, err := s.db.IsUsedLock(ctx, lockName)
 	if err != nil {
-		return "", fmt.Errorf("failed to get connection id: %w", err)
+		return "", err
 	}
-	fmt.Printf("[%s]before release session ID:%d", id, sID)
+	if !used {
+		return "", nil
+	}
+	return fmt.Sprintf("%d", owner), nil
+}
 
-	// ロックを解放
-	result, err = tx.ReleaseNamedLock(lockName)
+// RenewLock はリースの期限を延長する
+func (s *LockService) RenewLock(leaseID string, ttl time.Duration) (LeaseInfo, error) {
+	info, err := s.leases.Renew(leaseID, ttl)
 	if err != nil {
-		return sessionID, fmt.Errorf("failed to release: %w", err)
+		return LeaseInfo{}, fmt.Errorf("failed to renew lease: %w", err)
 	}
-	if !result {
-		return sessionID, fmt.Errorf("failed to release: result %v", result)
+
+	fmt.Printf("[%s] Lease renewed: %s, expires at: %s\n", leaseID, info.LockName, info.ExpiresAt.Format(time.RFC3339))
+
+	return info, nil
+}
+
+// ReleaseLock はAcquireLockで取得したロックを解放し、接続をプールに戻す
+// leaseIDが空でない場合は、保持しているリースのIDと一致する場合のみ解放する
+func (s *LockService) ReleaseLock(ctx context.Context, lockName string, leaseID string) (string, error) {
+	lease, err := s.leases.Remove(lockName, leaseID)
+	if err != nil {
+		sessionID := ""
+		if lease != nil {
+			sessionID = lease.SessionID
+		}
+		return sessionID, err
 	}
 
-	// トランザクションをコミット
-	if err := tx.Commit(); err != nil {
-		return sessionID, fmt.Errorf("failed to commit transaction: %w", err)
+	// ロックを解放
+	if err := lease.release(ctx); err != nil {
+		return lease.SessionID, err
 	}
 
-	return sessionID, nil
+	fmt.Printf("[%s] Lock released: %s\n", lease.ID, lockName)
+
+	return lease.SessionID, nil
 }
 
-// AcquireProductReleaseLock はロックを取得し、在庫を更新後、解放する
-// ロックの取得と解放の間にトランザクションを張る
-// 商品在庫を増やす処理を行う
-func (s *LockService) AcquireProductReleaseLock(ctx context.Context, productCode string, addQuantity int, timeout int) error {
-	id := uuid.New().String()
+// GetPoolStats はコネクションプールの統計情報を返す
+func (s *LockService) GetPoolStats() sql.DBStats {
+	return s.db.Stats()
+}
 
-	// ロックを取得
-	conn, result, err := s.db.GetNamedLock(ctx, productCode, timeout)
-	if err != nil {
-		return fmt.Errorf("failed to acquire lock: %w", err)
+// HeldLockIncidents はロックを保持したままプールに戻ろうとした接続の数を返す
+func (s *LockService) HeldLockIncidents() int64 {
+	return s.db.HeldLockIncidents()
+}
+
+// ListLeases は保持中のリースと直近で期限切れになったリースの一覧を返す
+func (s *LockService) ListLeases() []LeaseInfo {
+	return s.leases.List()
+}
+
+// LockKey はロック名に対応するMySQLのロック名を返す
+func (s *LockService) LockKey(lockName string) string {
+	return s.db.LockKey(lockName)
+}
+
+// GetLockStatus はロックの状態を取得する
+// このサーバーがリースとして保持しているロックの場合は、保持している接続で問い合わせる
+func (s *LockService) GetLockStatus(ctx context.Context, lockName string) (*db.LockStatus, error) {
+	if lease, ok := s.leases.Get(lockName); ok {
+		return lease.lock.Status(ctx)
 	}
-	// ロック取得に失敗した場合
-	if !result {
-		return fmt.Errorf("failed to acquire lock: result %v", result)
+	return s.db.GetLockStatus(ctx, lockName)
+}
+
+// AcquireHoldReleaseLock はロックを取得し、指定された時間保持した後、解放する
+// ロックは設定で選択したバックエンド（LockerConfig.HoldReleaseBackend）のセッションで取得する
+// 保持中にリクエストがキャンセルされた場合やロックを失った場合は、待機を打ち切ってロックを解放する
+// fair の場合は順番待ち（公平モード）で取得し、順番待ちの統計を返す（それ以外の場合は nil）
+// 順番待ちは lock_queue テーブルを使うため、バックエンドに関係なくMySQLの名前付きロックで取得する
+func (s *LockService) AcquireHoldReleaseLock(ctx context.Context, lockName string, timeout int, holdDuration int, fair bool) (string, *db.QueueStats, error) {
+	if fair {
+		return s.acquireHoldReleaseFairLock(ctx, lockName, timeout, holdDuration)
 	}
-	defer conn.Close()
 
-	// トランザクションを開始
-	tx, err := s.db.BeginTx(ctx)
+	id := uuid.New().String()
+	session, err := s.locker.NewLocker(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return "", nil, err
 	}
-	defer tx.Rollback()
+	// リクエストがキャンセルされていても解放できるよう、キャンセルを引き継がないコンテキストを使う
+	defer session.Close(context.WithoutCancel(ctx))
 
-	// 在庫情報を取得（FOR UPDATE句を使用）
-	product, err := tx.GetProductForUpdate(productCode)
+	// ロックを取得（タイムアウトはロック待ちにのみ適用する）
+	waitCtx, cancel := db.LockWaitContext(ctx, timeout)
+	err = session.Acquire(waitCtx, lockName)
+	cancel()
 	if err != nil {
-		return fmt.Errorf("failed to get product: %w", err)
+		return session.SessionID(), nil, err
 	}
-	time.Sleep(1 * time.Second)
+	sessionID := session.SessionID()
+	fmt.Printf("[%s]after lock session ID:%s (%s)", id, sessionID, s.locker.Name())
 
-	// 在庫情報が存在する場合は更新、存在しない場合は挿入
-	if product != nil {
-		fmt.Printf("[%s] Found existing product ID: %s, current quantity: %d\n", id, product.Code, product.Quantity)
+	// 指定された時間だけ待機
+	// リクエストのキャンセルに加えて、ロックを失った時点でも打ち切る
+	select {
+	case <-time.After(time.Duration(holdDuration) * time.Second):
+	case <-ctx.Done():
+		return sessionID, nil, fmt.Errorf("hold interrupted: %w", ctx.Err())
+	case <-session.Lost():
+		return sessionID, nil, fmt.Errorf("hold interrupted: %w", &db.LockError{Op: "hold", LockName: lockName, Err: db.ErrLockLost})
+	}
 
-		// 在庫数を増やす
-		product.Quantity += addQuantity
-		if err := tx.UpdateInventory(product); err != nil {
-			return fmt.Errorf("failed to update product: %w", err)
-		}
+	fmt.Printf("[%s]before release session ID:%s", id, sessionID)
+	if err := session.Release(context.WithoutCancel(ctx), lockName); err != nil {
+		return sessionID, nil, err
+	}
+	return sessionID, nil, nil
+}
 
-		fmt.Printf("[%s] Updated product quantity to: %d\n", id, product.Quantity)
-	} else {
-		fmt.Printf("[%s] No existing product found, inserting new product...\n", id)
+// acquireHoldReleaseFairLock は順番待ち（公平モード）でロックを取得し、指定された時間保持した後、解放する
+// ロックを取得した接続でトランザクションを張り、保持時間の経過後にコミットしてからロックを解放する
+func (s *LockService) acquireHoldReleaseFairLock(ctx context.Context, lockName string, timeout int, holdDuration int) (string, *db.QueueStats, error) {
+	// 公平モードは lock_queue テーブルを使うため、バックエンドに関係なくデータベースが必要
+	if s.db == nil {
+		return "", nil, ErrDatabaseUnavailable
+	}
+	id := uuid.New().String()
+	sessionID := ""
 
-		// 新しい在庫情報を挿入
-		newProduct := &db.Product{
-			Code:     productCode,
-			Quantity: addQuantity,
+	stats, err := s.db.WithFairNamedLock(ctx, lockName, timeout, func(tx *db.Tx) error {
+		sID, err := tx.GetCurrentConnectionID()
+		if err != nil {
+			return fmt.Errorf("failed to get connection id: %w", err)
 		}
-		if err := tx.InsertInventory(newProduct); err != nil {
-			return fmt.Errorf("failed to insert newProduct: %w", err)
+		fmt.Printf("[%s]after lock session ID:%d", id, sID)
+		sessionID = fmt.Sprintf("%d", sID)
+
+		// 指定された時間だけ待機
+		// トランザクションのコンテキストは、リクエストのキャンセルに加えてロックを失った時点でもキャンセルされる
+		select {
+		case <-time.After(time.Duration(holdDuration) * time.Second):
+		case <-tx.Context().Done():
+			return fmt.Errorf("hold interrupted: %w", context.Cause(tx.Context()))
 		}
 
-		fmt.Printf("[%s] Inserted new product with quantity: %d\n", id, addQuantity)
+		fmt.Printf("[%s]before release session ID:%d", id, sID)
+		return nil
+	})
+	return sessionID, stats, err
+}
+
+// AcquireProductReleaseLock はロックを取得し、在庫を更新後、解放する
+// ロックを取得した接続でトランザクションを張り、コミットしてからロックを解放する
+// 商品在庫を増やす処理を行う
+func (s *LockService) AcquireProductReleaseLock(ctx context.Context, productCode string, addQuantity int, timeout int) error {
+	id := uuid.New().String()
+
+	err := s.db.WithNamedLock(ctx, productCode, timeout, func(tx *db.Tx) error {
+		// 在庫情報を取得（FOR UPDATE句を使用）
+		product, err := tx.GetProductForUpdate(productCode)
+		if err != nil {
+			return fmt.Errorf("failed to get product: %w", err)
+		}
+		time.Sleep(1 * time.Second)
+
+		// 在庫情報が存在する場合は更新、存在しない場合は挿入
+		if product != nil {
+			fmt.Printf("[%s] Found existing product ID: %s, current quantity: %d\n", id, product.Code, product.Quantity)
+
+			// 在庫数を増やす
+			product.Quantity += addQuantity
+			if err := tx.UpdateInventory(product); err != nil {
+				return fmt.Errorf("failed to update product: %w", err)
+			}
+
+			fmt.Printf("[%s] Updated product quantity to: %d\n", id, product.Quantity)
+		} else {
+			fmt.Printf("[%s] No existing product found, inserting new product...\n", id)
+
+			// 新しい在庫情報を挿入
+			newProduct := &db.Product{
+				Code:     productCode,
+				Quantity: addQuantity,
+			}
+			if err := tx.InsertInventory(newProduct); err != nil {
+				return fmt.Errorf("failed to insert newProduct: %w", err)
+			}
+
+			fmt.Printf("[%s] Inserted new product with quantity: %d\n", id, addQuantity)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	// トランザクションをコミット
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+	fmt.Printf("[%s] Transaction committed and lock released\n", id)
+
+	return nil
+}
+
+// DecrementProductLock はロックを取得し、在庫を減らしてから解放する
+// 在庫が足りない場合（商品が存在しない場合を含む）は在庫を変更せず、その時点の在庫数を持つ *InsufficientStockError を返す
+// quantity が1未満の場合はロックを取得せずに ErrInvalidQuantity を返す
+// 戻り値は更新後の在庫数
+func (s *LockService) DecrementProductLock(ctx context.Context, productCode string, quantity int, timeout int) (int, error) {
+	if quantity <= 0 {
+		return 0, fmt.Errorf("%w: %d", ErrInvalidQuantity, quantity)
 	}
+	id := uuid.New().String()
 
-	// ロックを解放
-	result, err = conn.ReleaseNamedLock(ctx, productCode)
+	var remaining int
+	err := s.db.WithNamedLock(ctx, productCode, timeout, func(tx *db.Tx) error {
+		// 在庫情報を取得（FOR UPDATE句を使用）
+		product, err := tx.GetProductForUpdate(productCode)
+		if err != nil {
+			return fmt.Errorf("failed to get product: %w", err)
+		}
+
+		current := 0
+		if product != nil {
+			current = product.Quantity
+			fmt.Printf("[%s] Found existing product ID: %s, current quantity: %d\n", id, product.Code, product.Quantity)
+		}
+		if product == nil || current < quantity {
+			// 在庫が0未満にならないよう、減らさずに終了する
+			return &InsufficientStockError{
+				ProductCode: productCode,
+				Requested:   quantity,
+				Current:     current,
+			}
+		}
+
+		// 在庫数を減らす
+		product.Quantity -= quantity
+		if err := tx.UpdateInventory(product); err != nil {
+			return fmt.Errorf("failed to update product: %w", err)
+		}
+		remaining = product.Quantity
+
+		fmt.Printf("[%s] Updated product quantity to: %d\n", id, product.Quantity)
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to release lock: %w", err)
-	}
-	if !result {
-		return fmt.Errorf("failed to release lock: result %v", result)
+		return 0, err
 	}
 
-	fmt.Printf("[%s] Lock released\n", id)
+	fmt.Printf("[%s] Transaction committed and lock released\n", id)
 
-	fmt.Printf("[%s] Transaction committed\n", id)
+	return remaining, nil
+}
 
-	return nil
+// GetProduct は商品情報を取得する（ロックは取得しない）
+// 商品が存在しない場合は nil を返す
+func (s *LockService) GetProduct(ctx context.Context, productCode string) (*db.Product, error) {
+	return s.db.GetProduct(ctx, productCode)
 }
 
 // AcquireOrderReleaseLock はロックを取得し、注文を挿入後、共通コードで取得して解放する
-// ロックの取得と解放の間にトランザクションを張る
+// ロックを取得した接続でトランザクションを張り、コミットしてからロックを解放する
+// 注文の挿入は商品コードの書き込みロックで行い、ListOrders の読み取りとは同時に実行しない
+// 書き込みロックは商品コードの名前付きロックも含むため、同じ商品の在庫の更新とも同時に実行しない
 func (s *LockService) AcquireOrderReleaseLock(ctx context.Context, code string, timeout int) error {
 	id := uuid.New().String()
 
-	// ロックを取得
-	conn, result, err := s.db.GetNamedLock(ctx, code, timeout)
-	if err != nil {
-		return fmt.Errorf("failed to acquire lock: %w", err)
-	}
-	// ロック取得に失敗した場合
-	if !result {
-		return fmt.Errorf("failed to acquire lock: result %v", result)
-	}
-	defer conn.Close()
+	err := s.db.WithWriteLock(ctx, code, timeout, func(tx *db.Tx) error {
+		newOrder := &db.Order{
+			ID:   id,
+			Code: code,
+		}
+		if err := tx.InsertOrder(newOrder); err != nil {
+			return fmt.Errorf("failed to insert order: %w", err)
+		}
+		orders, err := tx.ListOrderByCode(code)
+		if err != nil {
+			return fmt.Errorf("failed to list orders: %w", err)
+		}
 
-	// トランザクションを開始
-	tx, err := s.db.BeginTx(ctx)
+		fmt.Printf("[%s] Inserted new order with ID: %s, Code: %s, Total Orders: %d\n", id, newOrder.ID, newOrder.Code, len(orders))
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return err
 	}
-	defer tx.Rollback()
 
-	newOrder := &db.Order{
-		ID:   id,
-		Code: code,
-	}
-	if err := tx.InsertOrder(newOrder); err != nil {
-		return fmt.Errorf("failed to insert order: %w", err)
-	}
-	orders, err := tx.ListOrderByCode(code)
-	if err != nil {
-		return fmt.Errorf("failed to list orders: %w", err)
-	}
+	fmt.Printf("[%s] Transaction committed and lock released\n", id)
 
-	fmt.Printf("[%s] Inserted new order with ID: %s, Code: %s, Total Orders: %d\n", id, newOrder.ID, newOrder.Code, len(orders))
+	return nil
+}
 
-	// ロックを解放
-	result, err = conn.ReleaseNamedLock(ctx, code)
-	if err != nil {
-		return fmt.Errorf("failed to release lock: %w", err)
-	}
-	if !result {
-		return fmt.Errorf("failed to release lock: result %v", result)
-	}
+// ListOrders は商品コードの読み取りロックを取得し、注文の一覧を取得する
+// 同じ商品コードの一覧の取得は同時に実行でき、注文の挿入（書き込みロック）とは同時に実行しない
+// holdDuration: 一覧を取得した後、読み取りロックを保持したまま待機する時間（秒）
+// 戻り値: 注文の一覧と、ロックを保持していたセッションID
+func (s *LockService) ListOrders(ctx context.Context, code string, timeout int, holdDuration int) ([]*db.Order, string, error) {
+	var orders []*db.Order
+	sessionID := ""
+
+	err := s.db.WithReadLock(ctx, code, timeout, func(tx *db.Tx) error {
+		sID, err := tx.GetCurrentConnectionID()
+		if err != nil {
+			return fmt.Errorf("failed to get connection id: %w", err)
+		}
+		sessionID = fmt.Sprintf("%d", sID)
 
-	fmt.Printf("[%s] Lock released\n", id)
+		orders, err = tx.ListOrderByCode(code)
+		if err != nil {
+			return fmt.Errorf("failed to list orders: %w", err)
+		}
 
-	// トランザクションをコミット
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+		// 指定された時間だけ、読み取りロックを保持したまま待機
+		select {
+		case <-time.After(time.Duration(holdDuration) * time.Second):
+		case <-tx.Context().Done():
+			return fmt.Errorf("hold interrupted: %w", context.Cause(tx.Context()))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, sessionID, err
 	}
 
-	fmt.Printf("[%s] Transaction committed\n", id)
-
-	return nil
+	return orders, sessionID, nil
 }
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/example/named-lock/internal/db"
+	"github.com/example/named-lock/internal/locker"
+)
+
+func TestDecrementProductLockRejectsNonPositiveQuantity(t *testing.T) {
+	// 数量の確認はロックを取得する前に行うため、DBに接続しない
+	s := &LockService{}
+	for _, quantity := range []int{0, -1} {
+		if _, err := s.DecrementProductLock(context.Background(), "product123", quantity, 1); !errors.Is(err, ErrInvalidQuantity) {
+			t.Errorf("DecrementProductLock(quantity: %d) = %v, want ErrInvalidQuantity", quantity, err)
+		}
+	}
+}
+
+// newMemoryLockService はプロセス内のロックのバックエンドを使う LockService を作成する
+// MySQLに接続しないため、保持・解放（公平モードを除く）のみを使用できる
+func newMemoryLockService() (*LockService, locker.Backend) {
+	backend := locker.NewMemoryBackend()
+	return &LockService{locker: backend}, backend
+}
+
+func TestAcquireHoldReleaseLockWithMemoryBackend(t *testing.T) {
+	s, backend := newMemoryLockService()
+	ctx := context.Background()
+
+	sessionID, stats, err := s.AcquireHoldReleaseLock(ctx, "hold", 1, 0, false)
+	if err != nil {
+		t.Fatalf("AcquireHoldReleaseLock: %v", err)
+	}
+	if sessionID == "" || stats != nil {
+		t.Errorf("session ID = %q, stats = %v; want a session ID and no queue stats", sessionID, stats)
+	}
+
+	// 解放済みのため、他のセッションが取得できる
+	other, err := backend.NewLocker(ctx)
+	if err != nil {
+		t.Fatalf("NewLocker: %v", err)
+	}
+	defer other.Close(ctx)
+	if acquired, err := other.TryAcquire(ctx, "hold"); err != nil || !acquired {
+		t.Fatalf("TryAcquire after hold-and-release = %t, %v; want true, nil", acquired, err)
+	}
+}
+
+func TestAcquireHoldReleaseLockTimeout(t *testing.T) {
+	s, backend := newMemoryLockService()
+	ctx := context.Background()
+
+	holder, err := backend.NewLocker(ctx)
+	if err != nil {
+		t.Fatalf("NewLocker: %v", err)
+	}
+	defer holder.Close(ctx)
+	if err := holder.Acquire(ctx, "busy"); err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+
+	_, _, err = s.AcquireHoldReleaseLock(ctx, "busy", 0, 0, false)
+	if !errors.Is(err, db.ErrLockTimeout) {
+		t.Fatalf("error = %v, want ErrLockTimeout", err)
+	}
+}
+
+func TestAcquireHoldReleaseLockInterrupted(t *testing.T) {
+	s, backend := newMemoryLockService()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	_, _, err := s.AcquireHoldReleaseLock(ctx, "interrupted", -1, 60, false)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("error = %v, want context.Canceled", err)
+	}
+
+	// 保持を打ち切った場合もロックは解放されている
+	other, err := backend.NewLocker(context.Background())
+	if err != nil {
+		t.Fatalf("NewLocker: %v", err)
+	}
+	defer other.Close(context.Background())
+	if acquired, err := other.TryAcquire(context.Background(), "interrupted"); err != nil || !acquired {
+		t.Fatalf("TryAcquire after interrupted hold = %t, %v; want true, nil", acquired, err)
+	}
+}